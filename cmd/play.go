@@ -2,5 +2,5 @@ package main
 
 func main() {
 	// Use this main to play around with the cookies
-	// Run by typing './godo run' from root directory
+	// Run by typing './godo run' from the root directory or a sub directory
 }
@@ -0,0 +1,529 @@
+package cookies
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveFormat is a supported archive file format.
+type ArchiveFormat int
+
+const (
+	ArchiveTar ArchiveFormat = iota + 1
+	ArchiveTarGz
+	ArchiveZip
+)
+
+// ArchiveFormatOf returns the ArchiveFormat implied by the extension of the
+// file name 'f'; '.tar', '.tar.gz', '.tgz', or '.zip'.
+func ArchiveFormatOf(f string) (ArchiveFormat, error) {
+	switch f = strings.ToLower(f); {
+	case strings.HasSuffix(f, ".tar"):
+		return ArchiveTar, nil
+	case strings.HasSuffix(f, ".tar.gz"), strings.HasSuffix(f, ".tgz"):
+		return ArchiveTarGz, nil
+	case strings.HasSuffix(f, ".zip"):
+		return ArchiveZip, nil
+	default:
+		return 0, fmt.Errorf("Unknown archive format: %s", f)
+	}
+}
+
+// ArchiveOptions configures the creation of archives.
+type ArchiveOptions struct {
+	Format  ArchiveFormat // Derived from the archive file name if zero
+	Walk    WalkOptions   // Selects the files to archive
+	ModTime time.Time     // If set, used for all entries instead of file times
+}
+
+// CreateArchive creates the archive 'dst' containing the directory tree 'src'.
+// Entries are added in lexical order and if a ModTime is given the archive
+// contains no file times or ownership information so archiving the same tree
+// always produces the same bytes. The archive is written using WriteAtomic.
+func CreateArchive(dst, src string, opts ArchiveOptions) error {
+	return CreateArchiveFS(OSFS, dst, src, opts)
+}
+
+// CreateArchiveFS is CreateArchive for any FS.
+func CreateArchiveFS(fsys FS, dst, src string, opts ArchiveOptions) error {
+
+	if opts.Format == 0 {
+		var e error
+		if opts.Format, e = ArchiveFormatOf(dst); e != nil {
+			return e
+		}
+	}
+
+	if in, e := isWithin(src, dst); e != nil || in {
+		return fmt.Errorf("Archive is within source directory: %s in %s", dst, src)
+	}
+
+	return WriteAtomicFS(fsys, dst, 0666, func(w io.Writer) error {
+		return WriteArchiveFS(fsys, w, src, opts)
+	})
+}
+
+// WriteArchive writes an archive, in the format specified by 'opts', of the
+// directory tree 'src' to 'w'. See CreateArchive.
+func WriteArchive(w io.Writer, src string, opts ArchiveOptions) error {
+	return WriteArchiveFS(OSFS, w, src, opts)
+}
+
+// WriteArchiveFS is WriteArchive for any FS.
+func WriteArchiveFS(fsys FS, w io.Writer, src string, opts ArchiveOptions) error {
+
+	walkOpts := opts.Walk
+	walkOpts.Dirs = true
+
+	var entries []WalkEntry
+	e := WalkFS(fsys, src, walkOpts, func(entry WalkEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+
+	aw := archiveWriter{fs: fsys, modTime: opts.ModTime}
+
+	switch opts.Format {
+	case ArchiveTar:
+		return aw.writeTar(w, entries)
+	case ArchiveTarGz:
+		return aw.writeTarGz(w, entries)
+	case ArchiveZip:
+		return aw.writeZip(w, entries)
+	default:
+		return fmt.Errorf("Unknown archive format: %d", opts.Format)
+	}
+}
+
+type archiveWriter struct {
+	fs      FS
+	modTime time.Time // Used for all entries if set
+}
+
+func (aw archiveWriter) linkTarget(entry WalkEntry) (string, error) {
+	if entry.Info.Mode()&os.ModeSymlink == 0 {
+		return "", nil
+	}
+	return aw.fs.Readlink(entry.Path)
+}
+
+func (aw archiveWriter) writeTarGz(w io.Writer, entries []WalkEntry) error {
+	gw := gzip.NewWriter(w)
+	if e := aw.writeTar(gw, entries); e != nil {
+		gw.Close()
+		return e
+	}
+	return gw.Close()
+}
+
+func (aw archiveWriter) writeTar(w io.Writer, entries []WalkEntry) error {
+	tw := tar.NewWriter(w)
+
+	for _, entry := range entries {
+		if e := aw.writeTarEntry(tw, entry); e != nil {
+			tw.Close()
+			return e
+		}
+	}
+
+	return tw.Close()
+}
+
+func (aw archiveWriter) writeTarEntry(tw *tar.Writer, entry WalkEntry) error {
+	mode := entry.Info.Mode()
+	if !mode.IsDir() && !mode.IsRegular() && mode&os.ModeSymlink == 0 {
+		return nil
+	}
+
+	link, e := aw.linkTarget(entry)
+	if e != nil {
+		return e
+	}
+
+	hdr, e := tar.FileInfoHeader(entry.Info, link)
+	if e != nil {
+		return e
+	}
+
+	hdr.Name = entry.Rel
+	if mode.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if !aw.modTime.IsZero() {
+		hdr.ModTime = aw.modTime
+		hdr.AccessTime, hdr.ChangeTime = time.Time{}, time.Time{}
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+	}
+
+	if e := tw.WriteHeader(hdr); e != nil {
+		return e
+	}
+
+	if !mode.IsRegular() {
+		return nil
+	}
+	return aw.copyFileTo(tw, entry.Path)
+}
+
+func (aw archiveWriter) writeZip(w io.Writer, entries []WalkEntry) error {
+	zw := zip.NewWriter(w)
+
+	for _, entry := range entries {
+		if e := aw.writeZipEntry(zw, entry); e != nil {
+			zw.Close()
+			return e
+		}
+	}
+
+	return zw.Close()
+}
+
+func (aw archiveWriter) writeZipEntry(zw *zip.Writer, entry WalkEntry) error {
+	mode := entry.Info.Mode()
+	if !mode.IsDir() && !mode.IsRegular() && mode&os.ModeSymlink == 0 {
+		return nil
+	}
+
+	hdr, e := zip.FileInfoHeader(entry.Info)
+	if e != nil {
+		return e
+	}
+
+	hdr.Name = entry.Rel
+	if mode.IsDir() {
+		hdr.Name += "/"
+	} else {
+		hdr.Method = zip.Deflate
+	}
+
+	if !aw.modTime.IsZero() {
+		hdr.Modified = aw.modTime
+	}
+
+	w, e := zw.CreateHeader(hdr)
+	if e != nil {
+		return e
+	}
+
+	switch {
+	case mode.IsRegular():
+		return aw.copyFileTo(w, entry.Path)
+	case mode&os.ModeSymlink != 0:
+		link, e := aw.linkTarget(entry)
+		if e != nil {
+			return e
+		}
+		_, e = io.WriteString(w, link)
+		return e
+	default:
+		return nil
+	}
+}
+
+func (aw archiveWriter) copyFileTo(w io.Writer, f string) error {
+	file, e := aw.fs.Open(f)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	_, e = io.Copy(w, file)
+	return e
+}
+
+// ExtractOptions configures the extraction of archives.
+type ExtractOptions struct {
+	Format    ArchiveFormat // Derived from the archive file name if zero
+	Overwrite bool          // Replace existing files
+}
+
+// ExtractArchive extracts the archive 'src' into the directory 'dst'. Entries
+// that would be written outside of 'dst', such as those with absolute paths,
+// paths containing '..' that escape, or symbolic links pointing outside, are
+// rejected with an error. Only directories, regular files, and symbolic links
+// are extracted.
+func ExtractArchive(src, dst string, opts ExtractOptions) error {
+	return ExtractArchiveFS(OSFS, src, dst, opts)
+}
+
+// ExtractArchiveFS is ExtractArchive for any FS.
+func ExtractArchiveFS(fsys FS, src, dst string, opts ExtractOptions) error {
+
+	if opts.Format == 0 {
+		var e error
+		if opts.Format, e = ArchiveFormatOf(src); e != nil {
+			return e
+		}
+	}
+
+	if e := fsys.MkdirAll(dst, os.ModePerm); e != nil {
+		return e
+	}
+
+	x := extractor{fs: fsys, root: dst, opts: opts}
+
+	switch opts.Format {
+	case ArchiveTar, ArchiveTarGz:
+		return x.extractTarFile(src, opts.Format == ArchiveTarGz)
+	case ArchiveZip:
+		return x.extractZip(src)
+	default:
+		return fmt.Errorf("Unknown archive format: %d", opts.Format)
+	}
+}
+
+type extractor struct {
+	fs   FS
+	root string
+	opts ExtractOptions
+}
+
+func (x extractor) extractTarFile(src string, gz bool) error {
+	file, e := x.fs.Open(src)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if gz {
+		gr, e := gzip.NewReader(file)
+		if e != nil {
+			return e
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	return x.extractTar(r)
+}
+
+func (x extractor) extractTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, e := tr.Next()
+		if e == io.EOF {
+			return nil
+		}
+		if e != nil {
+			return e
+		}
+
+		info := hdr.FileInfo()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			e = x.dir(hdr.Name, info.Mode())
+		case tar.TypeReg:
+			e = x.file(hdr.Name, info.Mode(), hdr.ModTime, tr)
+		case tar.TypeSymlink:
+			e = x.symlink(hdr.Name, hdr.Linkname)
+		}
+
+		if e != nil {
+			return e
+		}
+	}
+}
+
+func (x extractor) extractZip(src string) error {
+	file, e := x.fs.Open(src)
+	if e != nil {
+		return e
+	}
+	defer file.Close()
+
+	stat, e := file.Stat()
+	if e != nil {
+		return e
+	}
+
+	ra, ok := file.(io.ReaderAt)
+	if !ok {
+		data, e := ioutil.ReadAll(file)
+		if e != nil {
+			return e
+		}
+		ra = bytes.NewReader(data)
+	}
+
+	zr, e := zip.NewReader(ra, stat.Size())
+	if e != nil {
+		return e
+	}
+
+	for _, f := range zr.File {
+		if e := x.zipEntry(f); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func (x extractor) zipEntry(f *zip.File) error {
+	mode := f.Mode()
+	if mode.IsDir() {
+		return x.dir(f.Name, mode)
+	}
+
+	r, e := f.Open()
+	if e != nil {
+		return e
+	}
+	defer r.Close()
+
+	if mode&os.ModeSymlink != 0 {
+		target := strings.Builder{}
+		if _, e := io.Copy(&target, r); e != nil {
+			return e
+		}
+		return x.symlink(f.Name, target.String())
+	}
+
+	return x.file(f.Name, mode, f.Modified, r)
+}
+
+// path returns the destination of the archive entry 'name' or an error if it
+// would be outside of the extraction root. The parent directory is resolved
+// using SafeResolveFS, so existing symbolic links, possibly created by earlier
+// entries, can't lead outside of the root, and created if missing.
+func (x extractor) path(name string) (string, error) {
+
+	rel, e := cleanArchiveName(name)
+	if e != nil {
+		return "", e
+	}
+
+	parent, e := SafeResolveFS(x.fs, x.root, path.Dir(rel))
+	if e != nil {
+		return "", Wrap(e, "Archive entry escapes destination: %s", name)
+	}
+	if e := x.fs.MkdirAll(parent, os.ModePerm); e != nil {
+		return "", e
+	}
+
+	return filepath.Join(parent, path.Base(rel)), nil
+}
+
+// cleanArchiveName returns the cleaned '/' separated form of the archive entry
+// 'name' or an error if it's absolute or escapes via '..'.
+func cleanArchiveName(name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("Archive entry has an absolute path: %s", name)
+	}
+
+	rel := path.Clean(name)
+	if rel == "." && !strings.HasSuffix(name, "/") {
+		return "", fmt.Errorf("Archive entry has no name: %q", name)
+	}
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("Archive entry escapes destination: %s", name)
+	}
+
+	return rel, nil
+}
+
+func (x extractor) dir(name string, mode os.FileMode) error {
+	if rel, e := cleanArchiveName(name); e == nil && rel == "." {
+		return nil // The root itself
+	}
+
+	d, e := x.path(name)
+	if e != nil {
+		return e
+	}
+	if e := x.fs.MkdirAll(d, mode.Perm()|0700); e != nil {
+		return e
+	}
+	return x.fs.Chmod(d, mode.Perm()|0700)
+}
+
+func (x extractor) file(name string, mode os.FileMode, modTime time.Time, r io.Reader) error {
+	f, e := x.path(name)
+	if e != nil {
+		return e
+	}
+
+	if e := x.prepare(f); e != nil {
+		return e
+	}
+
+	file, e := x.fs.OpenFile(f, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if e != nil {
+		return e
+	}
+
+	if _, e := io.Copy(file, r); e != nil {
+		file.Close()
+		return e
+	}
+
+	if e := file.Close(); e != nil {
+		return e
+	}
+
+	if modTime.IsZero() {
+		return nil
+	}
+	return x.fs.Chtimes(f, modTime, modTime)
+}
+
+func (x extractor) symlink(name, target string) error {
+	f, e := x.path(name)
+	if e != nil {
+		return e
+	}
+
+	rel, _ := cleanArchiveName(name)
+	if path.IsAbs(target) || filepath.IsAbs(target) {
+		return fmt.Errorf("Archive link is absolute: %s -> %s", name, target)
+	}
+
+	// Joined without cleaning so '..' is applied after any links are followed.
+	dest := path.Dir(rel) + "/" + filepath.ToSlash(target)
+	if _, e := SafeResolveFS(x.fs, x.root, dest); e != nil {
+		return Wrap(e, "Archive link escapes destination: %s -> %s", name, target)
+	}
+
+	if e := x.prepare(f); e != nil {
+		return e
+	}
+
+	return x.fs.Symlink(target, f)
+}
+
+// prepare removes any existing file at 'f', so links are never written
+// through, or returns an error if overwriting is not allowed.
+func (x extractor) prepare(f string) error {
+	info, e := x.fs.Lstat(f)
+	if os.IsNotExist(e) {
+		return nil
+	}
+	if e != nil {
+		return e
+	}
+
+	if !x.opts.Overwrite {
+		return fmt.Errorf("Destination already exists: %s", f)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("Destination is a directory: %s", f)
+	}
+	return x.fs.Remove(f)
+}
@@ -0,0 +1,159 @@
+package cookies_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+var testArchiveFiles = map[string][]byte{
+	"abc.txt":        []byte("Weatherwax"),
+	"nested/xyz.txt": []byte("Ogg"),
+	"empty/":         nil,
+}
+
+func TestCreateArchive_AND_ExtractArchive(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	src := filepath.Join(temp, "src")
+	require.Nil(t, cookies.CreateFiles(src, os.ModePerm, testArchiveFiles))
+	require.Nil(t, os.Symlink("abc.txt", src+"/link.txt"))
+
+	for _, name := range []string{"out.tar", "out.tar.gz", "out.zip"} {
+		f := filepath.Join(temp, name)
+		require.Nil(t, cookies.CreateArchive(f, src, cookies.ArchiveOptions{}), name)
+
+		dst := filepath.Join(temp, name+".d")
+		require.Nil(t, cookies.ExtractArchive(f, dst, cookies.ExtractOptions{}), name)
+
+		act, e := cookies.ReadFiles(dst, cookies.SnapshotOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, map[string][]byte{
+			"abc.txt":        []byte("Weatherwax"),
+			"link.txt":       []byte("Weatherwax"),
+			"nested/xyz.txt": []byte("Ogg"),
+			"empty/":         nil,
+		}, act, name)
+
+		target, e := os.Readlink(dst + "/link.txt")
+		require.Nil(t, e, name)
+		require.Equal(t, "abc.txt", target, name)
+
+		require.NotNil(t, cookies.ExtractArchive(f, dst, cookies.ExtractOptions{}), name)
+		require.Nil(t, cookies.ExtractArchive(f, dst, cookies.ExtractOptions{Overwrite: true}), name)
+	}
+
+	require.NotNil(t, cookies.CreateArchive(src+"/self.zip", src, cookies.ArchiveOptions{}))
+	require.NotNil(t, cookies.CreateArchive(temp+"/out.rar", src, cookies.ArchiveOptions{}))
+}
+
+func TestCreateArchiveFS_AND_ExtractArchiveFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		src := filepath.Join(root, "src")
+		require.Nil(t, cookies.CreateFilesFS(fsys, src, os.ModePerm, testArchiveFiles))
+		require.Nil(t, fsys.Symlink("abc.txt", filepath.Join(src, "link.txt")))
+
+		for _, name := range []string{"out.tar", "out.tar.gz", "out.zip"} {
+			f := filepath.Join(root, name)
+			require.Nil(t, cookies.CreateArchiveFS(fsys, f, src, cookies.ArchiveOptions{}), name)
+
+			dst := filepath.Join(root, name+".d")
+			require.Nil(t, cookies.ExtractArchiveFS(fsys, f, dst, cookies.ExtractOptions{}), name)
+
+			act, e := cookies.ReadFilesFS(fsys, dst, cookies.SnapshotOptions{})
+			require.Nil(t, e, "%+v", e)
+			require.Equal(t, map[string][]byte{
+				"abc.txt":        []byte("Weatherwax"),
+				"link.txt":       []byte("Weatherwax"),
+				"nested/xyz.txt": []byte("Ogg"),
+				"empty/":         nil,
+			}, act, name)
+
+			target, e := fsys.Readlink(filepath.Join(dst, "link.txt"))
+			require.Nil(t, e, name)
+			require.Equal(t, "abc.txt", target, name)
+		}
+	})
+}
+
+func TestWriteArchive_Deterministic(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	modTime := time.Date(2019, 4, 15, 21, 50, 33, 0, time.UTC)
+	write := func(src string, format cookies.ArchiveFormat, mtime time.Time) []byte {
+		require.Nil(t, cookies.CreateFiles(src, os.ModePerm, testArchiveFiles))
+		require.Nil(t, os.Chtimes(src+"/abc.txt", mtime, mtime))
+
+		buf := bytes.Buffer{}
+		e := cookies.WriteArchive(&buf, src, cookies.ArchiveOptions{Format: format, ModTime: modTime})
+		require.Nil(t, e, "%+v", e)
+		return buf.Bytes()
+	}
+
+	aTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	bTime := aTime.Add(time.Hour)
+
+	for _, format := range []cookies.ArchiveFormat{cookies.ArchiveTar, cookies.ArchiveTarGz, cookies.ArchiveZip} {
+		a := write(filepath.Join(temp, "a"), format, aTime)
+		b := write(filepath.Join(temp, "b"), format, bTime)
+		require.Equal(t, a, b)
+	}
+}
+
+func writeTestTar(t *testing.T, f string, hdrs ...*tar.Header) {
+	buf := bytes.Buffer{}
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range hdrs {
+		require.Nil(t, tw.WriteHeader(hdr))
+	}
+	require.Nil(t, tw.Close())
+	require.Nil(t, ioutil.WriteFile(f, buf.Bytes(), 0666))
+}
+
+func TestExtractArchive_Escapes(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	requireEscape := func(hdrs ...*tar.Header) {
+		f := filepath.Join(temp, "evil.tar")
+		writeTestTar(t, f, hdrs...)
+		dst := filepath.Join(temp, "dst")
+		require.NotNil(t, cookies.ExtractArchive(f, dst, cookies.ExtractOptions{}), "%+v", hdrs[len(hdrs)-1])
+		cookiestest.RequireNotExists(t, filepath.Join(temp, "evil.txt"))
+		require.Nil(t, os.RemoveAll(dst))
+	}
+
+	reg := func(name string) *tar.Header {
+		return &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644}
+	}
+	link := func(name, target string) *tar.Header {
+		return &tar.Header{Typeflag: tar.TypeSymlink, Name: name, Linkname: target}
+	}
+
+	requireEscape(reg("../evil.txt"))
+	requireEscape(reg("a/../../evil.txt"))
+	requireEscape(reg(filepath.Join(temp, "evil.txt")))
+	requireEscape(link("a", "/etc"))
+	requireEscape(link("a", "../"))
+	requireEscape(link("a", "."), link("b", "a/.."))
+
+	buf := bytes.Buffer{}
+	zw := zip.NewWriter(&buf)
+	_, e := zw.Create("../evil.txt")
+	require.Nil(t, e)
+	require.Nil(t, zw.Close())
+
+	f := filepath.Join(temp, "evil.zip")
+	require.Nil(t, ioutil.WriteFile(f, buf.Bytes(), 0666))
+	require.NotNil(t, cookies.ExtractArchive(f, filepath.Join(temp, "dst"), cookies.ExtractOptions{}))
+	cookiestest.RequireNotExists(t, filepath.Join(temp, "evil.txt"))
+}
@@ -0,0 +1,135 @@
+package cookies
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// WriteFileAtomic writes 'data' to the file 'f' in a crash safe manner, see
+// WriteAtomic.
+func WriteFileAtomic(f string, data []byte, mode os.FileMode) error {
+	return WriteFileAtomicFS(OSFS, f, data, mode)
+}
+
+// WriteFileAtomicFS is WriteFileAtomic for any FS.
+func WriteFileAtomicFS(fsys FS, f string, data []byte, mode os.FileMode) error {
+	return WriteAtomicFS(fsys, f, mode, func(w io.Writer) error {
+		_, e := w.Write(data)
+		return e
+	})
+}
+
+// WriteAtomic writes to the file 'f' in a crash safe manner such that 'f'
+// either holds its previous content or all of the new content, never a
+// partial write. 'write' is given a temporary file in the same directory as
+// 'f' which is flushed to disk then renamed over 'f'; finally, the directory
+// itself is flushed so the rename survives a crash. If 'write' fails the
+// temporary file is removed and 'f' is left untouched.
+//
+// The file is always replaced so 'mode', before the umask, becomes the mode
+// of 'f' even if 'f' already existed.
+func WriteAtomic(f string, mode os.FileMode, write func(io.Writer) error) error {
+	return writeAtomic(OSFS, f, mode, false, write)
+}
+
+// WriteAtomicFS is WriteAtomic for any FS.
+func WriteAtomicFS(fsys FS, f string, mode os.FileMode, write func(io.Writer) error) error {
+	return writeAtomic(fsys, f, mode, false, write)
+}
+
+// replaceAtomic is WriteAtomic with a mode of 0666 except an existing 'f'
+// keeps its mode.
+func replaceAtomic(fsys FS, f string, write func(io.Writer) error) error {
+	mode, exact := os.FileMode(0666), false
+	if stat, e := fsys.Stat(f); e == nil {
+		mode, exact = stat.Mode().Perm(), true
+	}
+	return writeAtomic(fsys, f, mode, exact, write)
+}
+
+// writeAtomic performs WriteAtomic. If 'exact' is true then 'mode' is applied
+// without regard for the umask.
+func writeAtomic(fsys FS, f string, mode os.FileMode, exact bool, write func(io.Writer) error) (e error) {
+
+	dir := filepath.Dir(f)
+	tmp, e := createTempFile(fsys, dir, filepath.Base(f), mode)
+	if e != nil {
+		return e
+	}
+
+	closed := false
+	defer func() {
+		if e == nil {
+			return
+		}
+		if !closed {
+			tmp.Close()
+		}
+		fsys.Remove(tmp.Name())
+	}()
+
+	if e = write(tmp); e != nil {
+		return e
+	}
+
+	if exact {
+		if e = tmp.Chmod(mode); e != nil {
+			return e
+		}
+	}
+
+	if e = tmp.Sync(); e != nil {
+		return e
+	}
+
+	closed = true
+	if e = tmp.Close(); e != nil {
+		return e
+	}
+
+	if e = fsys.Rename(tmp.Name(), f); e != nil {
+		return e
+	}
+
+	return syncDir(fsys, dir)
+}
+
+// createTempFile creates a new hidden file in 'dir' with a name derived from
+// 'base'. Unlike ioutil.TempFile, 'mode' is used so the usual umask applies.
+func createTempFile(fsys FS, dir, base string, mode os.FileMode) (File, error) {
+	for i := 0; i < 10000; i++ {
+		suffix := strconv.FormatUint(uint64(rand.Uint32()), 36)
+		name := filepath.Join(dir, "."+base+"."+suffix+".tmp")
+
+		f, e := fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, mode)
+		if os.IsExist(e) {
+			continue
+		}
+		return f, e
+	}
+	return nil, &os.PathError{Op: "createtemp", Path: dir, Err: os.ErrExist}
+}
+
+// syncDir flushes the directory 'dir' to disk. Platforms that can't sync
+// directories are ignored.
+func syncDir(fsys FS, dir string) error {
+	if _, ok := fsys.(osFS); ok && runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, e := fsys.Open(dir)
+	if e != nil {
+		return e
+	}
+
+	if e = d.Sync(); e != nil {
+		d.Close()
+		return e
+	}
+
+	return d.Close()
+}
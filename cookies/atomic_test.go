@@ -0,0 +1,125 @@
+package cookies_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func requireOnlyFiles(t *testing.T, dir string, exps ...string) {
+	infos, e := ioutil.ReadDir(dir)
+	require.Nil(t, e, "%+v", e)
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	require.Equal(t, exps, names)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "abc.txt")
+	require.Nil(t, cookies.WriteFileAtomic(f, []byte("Weatherwax"), 0600))
+	cookiestest.RequireFile(t, f, "Weatherwax")
+
+	require.Nil(t, cookies.WriteFileAtomic(f, []byte("Ogg"), 0600))
+	cookiestest.RequireFile(t, f, "Ogg")
+	requireOnlyFiles(t, temp, "abc.txt")
+}
+
+func TestWriteAtomic_Fails(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "abc.txt")
+	require.Nil(t, cookies.WriteFileAtomic(f, []byte("Weatherwax"), 0600))
+
+	e := cookies.WriteAtomic(f, 0600, func(w io.Writer) error {
+		w.Write([]byte("Gar"))
+		return errors.New("Broomstick crashed")
+	})
+
+	require.NotNil(t, e)
+	cookiestest.RequireFile(t, f, "Weatherwax")
+	requireOnlyFiles(t, temp, "abc.txt")
+}
+
+func TestCopyFileWith_Atomic(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	src, dst := filepath.Join(temp, "src.txt"), filepath.Join(temp, "dst.txt")
+	require.Nil(t, ioutil.WriteFile(src, []byte("Weatherwax"), 0600))
+	require.Nil(t, ioutil.WriteFile(dst, []byte("Ogg"), 0640))
+
+	require.NotNil(t, cookies.CopyFileWith(src, dst, cookies.CopyFileOptions{Atomic: true}))
+	require.NotNil(t, cookies.CopyFileWith(src, src, cookies.CopyFileOptions{Overwrite: true, Atomic: true}))
+
+	require.Nil(t, cookies.CopyFileWith(src, dst, cookies.CopyFileOptions{Overwrite: true, Atomic: true}))
+	cookiestest.RequireFile(t, dst, "Weatherwax")
+	requireOnlyFiles(t, temp, "dst.txt", "src.txt")
+
+	stat, e := os.Stat(dst)
+	require.Nil(t, e)
+	require.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+}
+
+func TestCopyFileWithFS_AtomicFails(t *testing.T) {
+	m := cookies.NewMemFS()
+	require.Nil(t, cookies.CreateFilesFS(m, "/root", os.ModePerm, map[string][]byte{
+		"src.txt": []byte("Weatherwax"),
+		"dst.txt": []byte("Ogg"),
+	}))
+
+	exp := errors.New("Broomstick crashed")
+	m.Fail("read", "/root/src.txt", exp)
+
+	e := cookies.CopyFileWithFS(m, "/root/src.txt", "/root/dst.txt", cookies.CopyFileOptions{Overwrite: true, Atomic: true})
+	require.True(t, errors.Is(e, exp), "%+v", e)
+	cookiestest.RequireFileFS(t, m, "/root/dst.txt", "Ogg")
+
+	infos, e := m.ReadDir("/root")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 2, len(infos))
+}
+
+func TestCopyFileContext_Atomic(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	src, dst := filepath.Join(temp, "src.txt"), filepath.Join(temp, "dst.txt")
+	require.Nil(t, ioutil.WriteFile(src, []byte("Weatherwax"), 0600))
+	require.Nil(t, ioutil.WriteFile(dst, []byte("Ogg"), 0640))
+
+	ctx := context.Background()
+	require.NotNil(t, cookies.CopyFileContext(ctx, src, dst, cookies.CopyOptions{Atomic: true}))
+	require.NotNil(t, cookies.CopyFileContext(ctx, src, src, cookies.CopyOptions{Overwrite: true, Atomic: true}))
+
+	require.Nil(t, cookies.CopyFileContext(ctx, src, dst, cookies.CopyOptions{Overwrite: true, Atomic: true}))
+	cookiestest.RequireFile(t, dst, "Weatherwax")
+
+	stat, e := os.Stat(dst)
+	require.Nil(t, e)
+	require.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+}
+
+func TestCreateFilesWith_Atomic(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	e := cookies.CreateFilesWith(temp, os.ModePerm, map[string][]byte{
+		"abc.txt":        []byte("Weatherwax"),
+		"nested/abc.txt": []byte("Garlick"),
+		"empty/":         nil,
+	}, cookies.CreateOptions{Atomic: true})
+	require.Nil(t, e)
+
+	cookiestest.RequireFile(t, temp+"/abc.txt", "Weatherwax")
+	cookiestest.RequireFile(t, temp+"/nested/abc.txt", "Garlick")
+	require.DirExists(t, temp+"/empty")
+}
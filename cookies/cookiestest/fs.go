@@ -0,0 +1,65 @@
+package cookiestest
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+)
+
+// EachFS runs 'test' as parallel subtests against a fresh cookies.MemFS,
+// rooted at '/root', and a Workspace on the host file system. Neither changes
+// the working directory so the subtests may run in parallel.
+func EachFS(t *testing.T, test func(t *testing.T, fsys cookies.FS, root string)) {
+	t.Helper()
+
+	t.Run("MemFS", func(t *testing.T) {
+		t.Parallel()
+		test(t, cookies.NewMemFS(), "/root")
+	})
+
+	t.Run("OSFS", func(t *testing.T) {
+		t.Parallel()
+		test(t, cookies.OSFS, NewWorkspace(t).Root)
+	})
+}
+
+// RequireFile fails the test unless the file 'f' exists and contains exactly
+// 'exp'.
+func RequireFile(t testing.TB, f, exp string) {
+	t.Helper()
+	RequireFileFS(t, cookies.OSFS, f, exp)
+}
+
+// RequireFileFS is RequireFile for any FS.
+func RequireFileFS(t testing.TB, fsys cookies.FS, f, exp string) {
+	t.Helper()
+
+	act, e := cookies.ReadFileFS(fsys, f)
+	if e != nil {
+		t.Fatalf("Failed to read %s: %+v", f, e)
+	}
+	if !bytes.Equal(act, []byte(exp)) {
+		t.Fatalf("Unexpected content in %s\nexp: %q\nact: %q", f, exp, act)
+	}
+}
+
+// RequireNotExists fails the test if 'f' exists.
+func RequireNotExists(t testing.TB, f string) {
+	t.Helper()
+	RequireNotExistsFS(t, cookies.OSFS, f)
+}
+
+// RequireNotExistsFS is RequireNotExists for any FS.
+func RequireNotExistsFS(t testing.TB, fsys cookies.FS, f string) {
+	t.Helper()
+
+	_, e := fsys.Lstat(f)
+	if e == nil {
+		t.Fatalf("Exists but shouldn't: %s", f)
+	}
+	if !os.IsNotExist(e) {
+		t.Fatalf("Failed to check %s: %+v", f, e)
+	}
+}
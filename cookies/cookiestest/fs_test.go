@@ -0,0 +1,44 @@
+package cookiestest
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEachFS(t *testing.T) {
+	mu := sync.Mutex{}
+	var roots []string
+
+	t.Run("Each", func(t *testing.T) {
+		EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+			mu.Lock()
+			roots = append(roots, root)
+			mu.Unlock()
+
+			f := filepath.Join(root, "abc.txt")
+			require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+				"abc.txt": []byte("Weatherwax"),
+			}))
+
+			RequireFileFS(t, fsys, f, "Weatherwax")
+			RequireNotExistsFS(t, fsys, filepath.Join(root, "missing.txt"))
+
+			tb := &fatalTB{T: t}
+			requireFatal(t, tb, func() { RequireFileFS(tb, fsys, f, "Ogg") })
+			requireFatal(t, tb, func() { RequireNotExistsFS(tb, fsys, f) })
+		})
+	})
+
+	require.Equal(t, 2, len(roots))
+	for _, root := range roots {
+		if root != "/root" {
+			_, e := os.Stat(root)
+			require.True(t, os.IsNotExist(e), "Workspace not removed: %s", root)
+		}
+	}
+}
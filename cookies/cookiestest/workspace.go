@@ -0,0 +1,150 @@
+// Package cookiestest provides utilities for testing code that works with
+// files, whether real or within a cookies.FS.
+package cookiestest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+)
+
+// Workspace is an isolated temporary directory for tests that work with real
+// files. It's removed once the test and all its subtests have completed,
+// whether they passed or failed. Any failure within a Workspace method stops
+// the test via t.Fatal:
+//
+//	func TestX(t *testing.T) {
+//		ws := NewWorkspace(t)
+//		ws.Create(map[string][]byte{
+//			"abc.txt": []byte("Weatherwax"),
+//		})
+//		...
+//		ws.RequireFile("abc.txt", "Weatherwax")
+//	}
+type Workspace struct {
+	Root string // Absolute path of the workspace with symbolic links resolved
+	t    testing.TB
+}
+
+// NewWorkspace creates a new empty Workspace for the test 't'.
+func NewWorkspace(t testing.TB) *Workspace {
+	t.Helper()
+
+	root, e := filepath.EvalSymlinks(t.TempDir())
+	if e != nil {
+		t.Fatalf("Failed to create workspace: %+v", e)
+	}
+
+	return &Workspace{Root: root, t: t}
+}
+
+// Path returns the absolute path of the '/' separated 'rel' within the
+// workspace. The root itself is returned if 'rel' is empty.
+func (ws *Workspace) Path(rel string) string {
+	return filepath.Join(ws.Root, filepath.FromSlash(rel))
+}
+
+// Rel returns the '/' separated path of 'p' relative to the workspace root.
+func (ws *Workspace) Rel(p string) string {
+	ws.t.Helper()
+
+	rel, e := filepath.Rel(ws.Root, p)
+	if e != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		ws.t.Fatalf("Not within the workspace: %s", p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Chdir changes the working directory to the workspace root and restores the
+// previous one when the test completes. The working directory is shared by
+// the whole process so tests using Chdir must not run in parallel.
+func (ws *Workspace) Chdir() {
+	ws.t.Helper()
+
+	home, e := os.Getwd()
+	if e != nil {
+		ws.t.Fatalf("Failed to get working directory: %+v", e)
+	}
+
+	if e := os.Chdir(ws.Root); e != nil {
+		ws.t.Fatalf("Failed to change into workspace: %+v", e)
+	}
+
+	ws.t.Cleanup(func() {
+		if e := os.Chdir(home); e != nil {
+			ws.t.Errorf("Failed to restore working directory: %+v", e)
+		}
+	})
+}
+
+// Create creates 'files', as cookies.CreateFiles does, within the workspace.
+func (ws *Workspace) Create(files map[string][]byte) {
+	ws.t.Helper()
+
+	if e := cookies.CreateFiles(ws.Root, os.ModePerm, files); e != nil {
+		ws.t.Fatalf("Failed to create workspace files: %+v", e)
+	}
+}
+
+// CreateTxtar creates the files within the txtar archive 'archive' within the
+// workspace.
+func (ws *Workspace) CreateTxtar(archive string) {
+	ws.t.Helper()
+
+	a := cookies.ParseTxtar([]byte(archive))
+	if e := cookies.CreateFilesFromTxtar(ws.Root, os.ModePerm, a); e != nil {
+		ws.t.Fatalf("Failed to create workspace files: %+v", e)
+	}
+}
+
+// ReadFile returns the content of the workspace file 'rel'.
+func (ws *Workspace) ReadFile(rel string) []byte {
+	ws.t.Helper()
+
+	data, e := ioutil.ReadFile(ws.Path(rel))
+	if e != nil {
+		ws.t.Fatalf("Failed to read workspace file: %+v", e)
+	}
+	return data
+}
+
+// RequireFile fails the test unless the workspace file 'rel' exists and
+// contains exactly 'exp'.
+func (ws *Workspace) RequireFile(rel, exp string) {
+	ws.t.Helper()
+
+	RequireFile(ws.t, ws.Path(rel), exp)
+}
+
+// RequireDir fails the test unless 'rel' is a directory within the workspace.
+func (ws *Workspace) RequireDir(rel string) {
+	ws.t.Helper()
+
+	stat, e := os.Stat(ws.Path(rel))
+	if e != nil {
+		ws.t.Fatalf("Missing directory %s: %+v", rel, e)
+	}
+	if !stat.IsDir() {
+		ws.t.Fatalf("Not a directory: %s", rel)
+	}
+}
+
+// RequireExists fails the test unless 'rel' exists within the workspace.
+func (ws *Workspace) RequireExists(rel string) {
+	ws.t.Helper()
+
+	if _, e := os.Lstat(ws.Path(rel)); e != nil {
+		ws.t.Fatalf("Missing %s: %+v", rel, e)
+	}
+}
+
+// RequireNotExists fails the test if 'rel' exists within the workspace.
+func (ws *Workspace) RequireNotExists(rel string) {
+	ws.t.Helper()
+
+	RequireNotExists(ws.t, ws.Path(rel))
+}
@@ -0,0 +1,91 @@
+package cookiestest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireWorkDir(t *testing.T, exp string) {
+	act, e := os.Getwd()
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, exp, act)
+}
+
+// fatalTB records the first fatal failure instead of failing the real test.
+type fatalTB struct {
+	*testing.T
+	msg string
+}
+
+func (tb *fatalTB) Fatalf(format string, args ...interface{}) {
+	tb.msg = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// requireFatal requires 'f' to fail 'tb' fatally.
+func requireFatal(t *testing.T, tb *fatalTB, f func()) {
+	tb.msg = ""
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	<-done
+	require.NotEmpty(t, tb.msg, "Expected a fatal failure")
+}
+
+func TestWorkspace(t *testing.T) {
+	var root string
+	home, e := os.Getwd()
+	require.Nil(t, e)
+
+	t.Run("Use", func(t *testing.T) {
+		ws := NewWorkspace(t)
+		root = ws.Root
+
+		ws.Create(map[string][]byte{
+			"abc.txt": []byte("Weatherwax"),
+			"empty/":  nil,
+		})
+		ws.CreateTxtar("-- a/xyz.txt --\nOgg\n")
+
+		ws.RequireFile("abc.txt", "Weatherwax")
+		ws.RequireFile("a/xyz.txt", "Ogg\n")
+		ws.RequireDir("empty")
+		ws.RequireExists("a")
+		ws.RequireNotExists("missing.txt")
+
+		require.Equal(t, filepath.Join(root, "a", "xyz.txt"), ws.Path("a/xyz.txt"))
+		require.Equal(t, "a/xyz.txt", ws.Rel(ws.Path("a/xyz.txt")))
+
+		ws.Chdir()
+		requireWorkDir(t, root)
+	})
+
+	requireWorkDir(t, home)
+	_, e = os.Stat(root)
+	require.True(t, os.IsNotExist(e), "Workspace not removed: %s", root)
+}
+
+func TestWorkspace_Failures(t *testing.T) {
+	tb := &fatalTB{T: t}
+	ws := NewWorkspace(tb)
+	ws.Create(map[string][]byte{
+		"abc.txt": []byte("Weatherwax"),
+	})
+
+	requireFatal(t, tb, func() { ws.RequireFile("abc.txt", "Ogg") })
+	requireFatal(t, tb, func() { ws.RequireFile("missing.txt", "") })
+	requireFatal(t, tb, func() { ws.RequireDir("abc.txt") })
+	requireFatal(t, tb, func() { ws.RequireExists("missing.txt") })
+	requireFatal(t, tb, func() { ws.RequireNotExists("abc.txt") })
+	requireFatal(t, tb, func() { ws.Rel(filepath.Dir(ws.Root)) })
+	requireFatal(t, tb, func() {
+		ws.Create(map[string][]byte{"../escape.txt": nil})
+	})
+}
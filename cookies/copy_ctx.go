@@ -0,0 +1,202 @@
+package cookies
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// defaultProgressInterval is used when CopyOptions.Interval is zero.
+const defaultProgressInterval = 500 * time.Millisecond
+
+// copyBufSize is the largest chunk read from the source at a time.
+const copyBufSize = 32 * 1024
+
+// CopyOptions configures CopyFileContext and NoCheckCopyFileContext.
+type CopyOptions struct {
+	Overwrite bool                      // Replace an existing destination, ignored by NoCheckCopyFileContext
+	Atomic    bool                      // Write as WriteAtomic does so 'dst' is never left partially written
+	Progress  func(copied, total int64) // Called every Interval and once when done, may be nil
+	Interval  time.Duration             // Between Progress calls, 500ms if zero
+	Limit     int64                     // Maximum bytes per second, unlimited if zero
+}
+
+// CopyFileContext is CopyFile configured by 'opts' so it may report progress,
+// be throttled, or write atomically. It stops when 'ctx' is cancelled
+// returning the context's error. On cancellation, or any other failure, the
+// partially written 'dst' is removed, or if opts.Atomic is set, 'dst' is left
+// untouched.
+func CopyFileContext(ctx context.Context, src, dst string, opts CopyOptions) error {
+	return CopyFileContextFS(ctx, OSFS, src, dst, opts)
+}
+
+// CopyFileContextFS is CopyFileContext for any FS.
+func CopyFileContextFS(ctx context.Context, fsys FS, src, dst string, opts CopyOptions) error {
+	if e := checkCopyFile(fsys, src, dst, opts.Overwrite); e != nil {
+		return e
+	}
+	return NoCheckCopyFileContextFS(ctx, fsys, src, dst, opts)
+}
+
+// NoCheckCopyFileContext is NoCheckCopyFile configured as CopyFileContext is.
+// If opts.Atomic is set and 'dst' already exists its mode is kept.
+func NoCheckCopyFileContext(ctx context.Context, src, dst string, opts CopyOptions) error {
+	return NoCheckCopyFileContextFS(ctx, OSFS, src, dst, opts)
+}
+
+// NoCheckCopyFileContextFS is NoCheckCopyFileContext for any FS.
+func NoCheckCopyFileContextFS(ctx context.Context, fsys FS, src, dst string, opts CopyOptions) error {
+
+	info, e := fsys.Stat(src)
+	if e != nil {
+		return e
+	}
+
+	c := newCopier(opts, info.Size())
+	if e := c.copyFile(ctx, fsys, src, dst); e != nil {
+		return e
+	}
+
+	c.finish()
+	return nil
+}
+
+// copier performs the copies of the context aware copy functions. A single
+// copier may perform many copies, e.g. of a whole tree, in which case progress
+// and throttling apply to the copies as a whole.
+type copier struct {
+	opts   CopyOptions
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	total  int64 // Bytes expected across all copies
+	copied int64
+	start  time.Time // Of the first copy
+	last   time.Time // Progress was last reported
+}
+
+func newCopier(opts CopyOptions, total int64) *copier {
+	if opts.Interval == 0 {
+		opts.Interval = defaultProgressInterval
+	}
+	return &copier{opts: opts, now: time.Now, sleep: sleepContext, total: total}
+}
+
+// copyFile copies the file 'src' to 'dst', atomically if requested. If the
+// copy fails its bytes are no longer counted as copied.
+func (c *copier) copyFile(ctx context.Context, fsys FS, src, dst string) (e error) {
+
+	srcFile, e := fsys.Open(src)
+	if e != nil {
+		return e
+	}
+	defer srcFile.Close()
+
+	copied := c.copied
+	defer func() {
+		if e != nil {
+			c.copied = copied
+		}
+	}()
+
+	if c.opts.Atomic {
+		return replaceAtomic(fsys, dst, func(w io.Writer) error {
+			return c.copy(ctx, w, srcFile)
+		})
+	}
+
+	dstFile, e := fsys.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if e != nil {
+		return e
+	}
+
+	if e = c.copy(ctx, dstFile, srcFile); e != nil {
+		dstFile.Close()
+		fsys.Remove(dst)
+		return e
+	}
+
+	if e = dstFile.Close(); e != nil {
+		fsys.Remove(dst)
+		return e
+	}
+	return nil
+}
+
+// copy copies 'r' to 'w' in chunks checking 'ctx' before each one. When
+// throttled, chunks are kept to a tenth of the limit so the rate stays smooth
+// and cancellation is noticed promptly.
+func (c *copier) copy(ctx context.Context, w io.Writer, r io.Reader) error {
+
+	if c.start.IsZero() {
+		c.start = c.now()
+		c.last = c.start
+	}
+
+	size := int64(copyBufSize)
+	if c.opts.Limit > 0 && c.opts.Limit/10 < size {
+		size = c.opts.Limit / 10
+		if size < 1 {
+			size = 1
+		}
+	}
+
+	buf := make([]byte, size)
+
+	for {
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			if _, e := w.Write(buf[:n]); e != nil {
+				return e
+			}
+			c.copied += int64(n)
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+
+		if c.opts.Limit > 0 {
+			due := c.start.Add(time.Duration(c.copied * int64(time.Second) / c.opts.Limit))
+			if e := c.sleep(ctx, due.Sub(c.now())); e != nil {
+				return e
+			}
+		}
+
+		if c.opts.Progress != nil && c.now().Sub(c.last) >= c.opts.Interval {
+			c.last = c.now()
+			c.opts.Progress(c.copied, c.total)
+		}
+	}
+}
+
+// finish reports the final progress once all copies are complete.
+func (c *copier) finish() {
+	if c.opts.Progress != nil {
+		c.opts.Progress(c.copied, c.total)
+	}
+}
+
+// sleepContext pauses for 'd' or until 'ctx' is cancelled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+package cookies
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopier_Limit(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("x", 8*1024)
+	c := newCopier(CopyOptions{Limit: 64 * 1024}, int64(len(content)))
+	clock := time.Date(2019, 4, 15, 21, 50, 33, 0, time.UTC)
+	var delays []time.Duration
+
+	c.now = func() time.Time {
+		return clock
+	}
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		clock = clock.Add(d)
+		return nil
+	}
+
+	w := bytes.Buffer{}
+	e := c.copy(context.Background(), &w, strings.NewReader(content))
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, content, w.String())
+
+	// Chunks are a tenth of the limit, 6553 bytes, and each is delayed until
+	// the bytes copied so far are due at 64KiB per second.
+	require.Equal(t, []time.Duration{
+		6553 * time.Second / (64 * 1024),
+		125*time.Millisecond - 6553*time.Second/(64*1024),
+	}, delays)
+}
@@ -0,0 +1,79 @@
+package cookies_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFileContext(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		content := strings.Repeat("Weatherwax", 10000)
+		src, dst := filepath.Join(root, "src.txt"), filepath.Join(root, "dst.txt")
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"src.txt": []byte(content),
+		}))
+
+		var calls [][2]int64
+		opts := cookies.CopyOptions{
+			Interval: time.Nanosecond,
+			Progress: func(copied, total int64) {
+				calls = append(calls, [2]int64{copied, total})
+			},
+		}
+
+		e := cookies.CopyFileContextFS(context.Background(), fsys, src, dst, opts)
+		require.Nil(t, e, "%+v", e)
+		cookiestest.RequireFileFS(t, fsys, dst, content)
+
+		require.True(t, len(calls) > 1)
+		for i := 1; i < len(calls); i++ {
+			require.True(t, calls[i][0] >= calls[i-1][0])
+		}
+		require.Equal(t, [2]int64{100000, 100000}, calls[len(calls)-1])
+
+		require.NotNil(t, cookies.CopyFileContextFS(context.Background(), fsys, src, dst, opts))
+	})
+}
+
+func TestCopyFileContext_Cancel(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		src, dst := filepath.Join(root, "src.txt"), filepath.Join(root, "dst.txt")
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"src.txt": []byte(strings.Repeat("Weatherwax", 10000)),
+			"dst.txt": []byte("Ogg"),
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		opts := cookies.CopyOptions{
+			Overwrite: true,
+			Atomic:    true,
+			Interval:  time.Nanosecond,
+			Progress: func(copied, total int64) {
+				cancel()
+			},
+		}
+
+		e := cookies.CopyFileContextFS(ctx, fsys, src, dst, opts)
+		require.True(t, errors.Is(e, context.Canceled), "%+v", e)
+		cookiestest.RequireFileFS(t, fsys, dst, "Ogg")
+
+		opts.Atomic = false
+		e = cookies.CopyFileContextFS(ctx, fsys, src, dst, opts)
+		require.True(t, errors.Is(e, context.Canceled), "%+v", e)
+		ok, e := cookies.FileExistsFS(fsys, dst)
+		require.Nil(t, e, "%+v", e)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,337 @@
+package cookies
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy specifies how symbolic links are handled when copying a
+// directory tree.
+type SymlinkPolicy int
+
+const (
+	SymlinkFollow SymlinkPolicy = iota // Copy whatever the link points to
+	SymlinkCopy                        // Recreate the link at the destination
+	SymlinkSkip                        // Ignore the link altogether
+)
+
+// CopyDirOptions configures CopyDir and CopyDirContext.
+type CopyDirOptions struct {
+	Symlinks SymlinkPolicy // How symbolic links are handled
+	Copy     CopyOptions   // How files are copied, progress covers the whole tree
+}
+
+// CopyDirFailure is a file that CopyDir was unable to copy.
+type CopyDirFailure struct {
+	Path string // Relative to the source directory
+	Err  error
+}
+
+// CopyDirReport lists the files, relative to the source directory and
+// separated by '/', that were copied, skipped, or failed during a CopyDir.
+type CopyDirReport struct {
+	Copied  []string
+	Skipped []string
+	Failed  []CopyDirFailure
+}
+
+// CopyDir recursively copies the directory 'src' to 'dst' creating 'dst' if
+// it doesn't exist. Each regular file is copied as CopyFileContext does, with
+// opts.Copy, so the same overwrite and same file checks apply, after which
+// the source file mode and modification time are applied. Directories have
+// their modes and modification times preserved too. Recreated symbolic links
+// only replace existing files if opts.Copy.Overwrite is set. Files that are
+// neither regular, directories, nor symbolic links are skipped.
+//
+// A failure to copy a single file does not stop the copy, instead it is
+// recorded in the report and an error is returned once the copy is complete.
+func CopyDir(src, dst string, opts CopyDirOptions) (CopyDirReport, error) {
+	return CopyDirContextFS(context.Background(), OSFS, src, dst, opts)
+}
+
+// CopyDirFS is CopyDir for any FS.
+func CopyDirFS(fsys FS, src, dst string, opts CopyDirOptions) (CopyDirReport, error) {
+	return CopyDirContextFS(context.Background(), fsys, src, dst, opts)
+}
+
+// CopyDirContext is CopyDir but stops when 'ctx' is cancelled, returning the
+// report so far and the context's error. opts.Copy.Progress is given the
+// bytes copied out of the total size of all files to be copied.
+func CopyDirContext(ctx context.Context, src, dst string, opts CopyDirOptions) (CopyDirReport, error) {
+	return CopyDirContextFS(ctx, OSFS, src, dst, opts)
+}
+
+// CopyDirContextFS is CopyDirContext for any FS.
+func CopyDirContextFS(ctx context.Context, fsys FS, src, dst string, opts CopyDirOptions) (CopyDirReport, error) {
+
+	r := CopyDirReport{}
+
+	srcInfo, e := fsys.Stat(src)
+	if e != nil || !srcInfo.IsDir() {
+		return r, fmt.Errorf("Missing or not a directory: %s", src)
+	}
+
+	if in, e := isWithin(src, dst); e != nil || in {
+		return r, fmt.Errorf("Destination is within source: %s in %s", dst, src)
+	}
+
+	realSrc, e := evalSymlinksFS(fsys, src)
+	if e != nil {
+		return r, e
+	}
+
+	c := &dirCopier{fs: fsys, opts: opts, report: &r}
+	if e := c.planDir(src, dst, "", srcInfo, []string{realSrc}); e != nil {
+		return r, e
+	}
+
+	if e := c.copy(ctx); e != nil {
+		return r, e
+	}
+
+	if n := len(r.Failed); n > 0 {
+		return r, fmt.Errorf("Failed to copy %d file(s) from %s", n, src)
+	}
+
+	return r, nil
+}
+
+// isWithin returns true if 'p' is 'dir' or lexically within it.
+func isWithin(dir, p string) (bool, error) {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return false, e
+	}
+	p, e = filepath.Abs(p)
+	if e != nil {
+		return false, e
+	}
+	return p == dir || strings.HasPrefix(p, dir+string(filepath.Separator)), nil
+}
+
+// preserveAttrs applies the mode and modification time within 'info' to the
+// file 'f'.
+func preserveAttrs(fsys FS, f string, info os.FileInfo) error {
+	if e := fsys.Chmod(f, info.Mode().Perm()); e != nil {
+		return e
+	}
+	return fsys.Chtimes(f, info.ModTime(), info.ModTime())
+}
+
+// dirCopier copies a tree in two passes. The first creates the directories
+// and plans the copying of files and symbolic links so the total size is
+// known before any file is copied. The second performs the plan then applies
+// the directory attributes, deepest first, since adding files changes them.
+type dirCopier struct {
+	fs     FS
+	opts   CopyDirOptions
+	report *CopyDirReport
+	jobs   []copyJob
+	dirs   []copyJob // Directories in the order created
+	total  int64     // Size of the files to copy
+}
+
+// copyJob is a planned copy of 'src' to 'dst'.
+type copyJob struct {
+	src, dst, rel string
+	info          os.FileInfo
+}
+
+func (c *dirCopier) fail(rel string, e error) {
+	c.report.Failed = append(c.report.Failed, CopyDirFailure{rel, e})
+}
+
+func (c *dirCopier) skip(rel string) {
+	c.report.Skipped = append(c.report.Skipped, rel)
+}
+
+// planDir creates 'dst' and plans the copying of the contents of 'src'.
+// 'chain' holds the real paths of 'src' and its ancestors so symbolic link
+// loops can be detected.
+func (c *dirCopier) planDir(src, dst, rel string, info os.FileInfo, chain []string) error {
+
+	// Owner write permission is needed while populating the directory, the
+	// real mode is applied once the copy is complete.
+	if e := c.fs.MkdirAll(dst, info.Mode().Perm()|0700); e != nil {
+		return e
+	}
+	c.dirs = append(c.dirs, copyJob{src, dst, rel, info})
+
+	entries, e := c.fs.ReadDir(src)
+	if e != nil {
+		return e
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		c.planEntry(
+			filepath.Join(src, name),
+			filepath.Join(dst, name),
+			path.Join(rel, name),
+			entry,
+			chain,
+		)
+	}
+
+	return nil
+}
+
+func (c *dirCopier) planEntry(src, dst, rel string, info os.FileInfo, chain []string) {
+
+	mode := info.Mode()
+
+	switch {
+	case mode&os.ModeSymlink != 0:
+		c.planSymlink(src, dst, rel, info, chain)
+
+	case mode.IsDir():
+		if e := c.planDir(src, dst, rel, info, chain); e != nil {
+			c.fail(rel, e)
+		}
+
+	case mode.IsRegular():
+		if !c.opts.Copy.Overwrite {
+			if ok, e := FileExistsFS(c.fs, dst); e != nil || ok {
+				c.skip(rel)
+				return
+			}
+		}
+		c.jobs = append(c.jobs, copyJob{src, dst, rel, info})
+		c.total += info.Size()
+
+	default:
+		c.skip(rel)
+	}
+}
+
+func (c *dirCopier) planSymlink(src, dst, rel string, info os.FileInfo, chain []string) {
+
+	switch c.opts.Symlinks {
+	case SymlinkSkip:
+		c.skip(rel)
+
+	case SymlinkCopy:
+		c.jobs = append(c.jobs, copyJob{src, dst, rel, info})
+
+	default:
+		c.followSymlink(src, dst, rel, chain)
+	}
+}
+
+func (c *dirCopier) followSymlink(src, dst, rel string, chain []string) {
+
+	info, e := c.fs.Stat(src)
+	if e != nil {
+		c.fail(rel, e)
+		return
+	}
+
+	if !info.IsDir() {
+		c.planEntry(src, dst, rel, info, chain)
+		return
+	}
+
+	real, e := evalSymlinksFS(c.fs, src)
+	if e != nil {
+		c.fail(rel, e)
+		return
+	}
+
+	for _, p := range chain {
+		if p == real {
+			c.fail(rel, fmt.Errorf("Symbolic link loop: %s -> %s", src, real))
+			return
+		}
+	}
+
+	chain = append(chain[:len(chain):len(chain)], real)
+	if e := c.planDir(src, dst, rel, info, chain); e != nil {
+		c.fail(rel, e)
+	}
+}
+
+// copy performs the planned copies then applies the directory attributes.
+func (c *dirCopier) copy(ctx context.Context) error {
+
+	cp := newCopier(c.opts.Copy, c.total)
+
+	for _, job := range c.jobs {
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+
+		if job.info.Mode()&os.ModeSymlink != 0 {
+			c.recreateSymlink(job)
+		} else {
+			c.copyFile(ctx, cp, job)
+		}
+	}
+
+	if e := ctx.Err(); e != nil {
+		return e
+	}
+	cp.finish()
+
+	for i := len(c.dirs) - 1; i >= 0; i-- {
+		d := c.dirs[i]
+		if e := preserveAttrs(c.fs, d.dst, d.info); e != nil {
+			if i == 0 {
+				return e
+			}
+			c.fail(d.rel, e)
+		}
+	}
+
+	return nil
+}
+
+func (c *dirCopier) copyFile(ctx context.Context, cp *copier, job copyJob) {
+
+	if e := checkCopyFile(c.fs, job.src, job.dst, c.opts.Copy.Overwrite); e != nil {
+		c.fail(job.rel, e)
+		return
+	}
+
+	if e := cp.copyFile(ctx, c.fs, job.src, job.dst); e != nil {
+		c.fail(job.rel, e)
+		return
+	}
+
+	if e := preserveAttrs(c.fs, job.dst, job.info); e != nil {
+		c.fail(job.rel, e)
+		return
+	}
+
+	c.report.Copied = append(c.report.Copied, job.rel)
+}
+
+func (c *dirCopier) recreateSymlink(job copyJob) {
+
+	target, e := c.fs.Readlink(job.src)
+	if e != nil {
+		c.fail(job.rel, e)
+		return
+	}
+
+	if _, e := c.fs.Lstat(job.dst); e == nil {
+		if !c.opts.Copy.Overwrite {
+			c.skip(job.rel)
+			return
+		}
+		if e := c.fs.Remove(job.dst); e != nil {
+			c.fail(job.rel, e)
+			return
+		}
+	}
+
+	if e := c.fs.Symlink(target, job.dst); e != nil {
+		c.fail(job.rel, e)
+		return
+	}
+
+	c.report.Copied = append(c.report.Copied, job.rel)
+}
@@ -0,0 +1,182 @@
+package cookies_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyDir(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	src := filepath.Join(temp, "src")
+	e := cookies.CreateFiles(src, os.ModePerm, map[string][]byte{
+		"abc.txt":        []byte("Weatherwax"),
+		"nested/xyz.txt": []byte("Ogg"),
+		"empty/":         nil,
+	})
+	require.Nil(t, e)
+
+	mtime := time.Date(2019, 4, 15, 21, 50, 33, 0, time.UTC)
+	require.Nil(t, os.Chmod(src+"/abc.txt", 0600))
+	require.Nil(t, os.Chtimes(src+"/abc.txt", mtime, mtime))
+
+	dst := filepath.Join(temp, "dst")
+	r, e := cookies.CopyDir(src, dst, cookies.CopyDirOptions{})
+	require.Nil(t, e, "%+v", e)
+
+	require.Equal(t, []string{"abc.txt", "nested/xyz.txt"}, r.Copied)
+	require.Empty(t, r.Skipped)
+	require.Empty(t, r.Failed)
+
+	cookiestest.RequireFile(t, dst+"/abc.txt", "Weatherwax")
+	cookiestest.RequireFile(t, dst+"/nested/xyz.txt", "Ogg")
+	require.DirExists(t, dst+"/empty")
+
+	stat, e := os.Stat(dst + "/abc.txt")
+	require.Nil(t, e)
+	require.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+	require.True(t, mtime.Equal(stat.ModTime()))
+}
+
+func TestCopyDir_Overwrite(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	src, dst := filepath.Join(temp, "src"), filepath.Join(temp, "dst")
+	require.Nil(t, cookies.CreateFiles(src, os.ModePerm, map[string][]byte{
+		"abc.txt": []byte("Weatherwax"),
+		"xyz.txt": []byte("Ogg"),
+	}))
+	require.Nil(t, cookies.CreateFiles(dst, os.ModePerm, map[string][]byte{
+		"abc.txt": []byte("Garlick"),
+	}))
+
+	r, e := cookies.CopyDir(src, dst, cookies.CopyDirOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []string{"xyz.txt"}, r.Copied)
+	require.Equal(t, []string{"abc.txt"}, r.Skipped)
+	cookiestest.RequireFile(t, dst+"/abc.txt", "Garlick")
+
+	r, e = cookies.CopyDir(src, dst, cookies.CopyDirOptions{Copy: cookies.CopyOptions{Overwrite: true}})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []string{"abc.txt", "xyz.txt"}, r.Copied)
+	cookiestest.RequireFile(t, dst+"/abc.txt", "Weatherwax")
+}
+
+func TestCopyDir_Symlinks(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	src := filepath.Join(temp, "src")
+	require.Nil(t, cookies.CreateFiles(src, os.ModePerm, map[string][]byte{
+		"abc.txt": []byte("Weatherwax"),
+	}))
+	require.Nil(t, os.Symlink("abc.txt", src+"/link.txt"))
+	require.Nil(t, os.Symlink(".", src+"/loop"))
+
+	dst := filepath.Join(temp, "follow")
+	r, e := cookies.CopyDir(src, dst, cookies.CopyDirOptions{Symlinks: cookies.SymlinkFollow})
+	require.NotNil(t, e)
+	require.Equal(t, []string{"abc.txt", "link.txt"}, r.Copied)
+	require.Equal(t, 1, len(r.Failed))
+	require.Equal(t, "loop", r.Failed[0].Path)
+	cookiestest.RequireFile(t, dst+"/link.txt", "Weatherwax")
+
+	dst = filepath.Join(temp, "copy")
+	r, e = cookies.CopyDir(src, dst, cookies.CopyDirOptions{Symlinks: cookies.SymlinkCopy})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []string{"abc.txt", "link.txt", "loop"}, r.Copied)
+	target, e := os.Readlink(dst + "/link.txt")
+	require.Nil(t, e)
+	require.Equal(t, "abc.txt", target)
+
+	dst = filepath.Join(temp, "skip")
+	r, e = cookies.CopyDir(src, dst, cookies.CopyDirOptions{Symlinks: cookies.SymlinkSkip})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []string{"abc.txt"}, r.Copied)
+	require.Equal(t, []string{"link.txt", "loop"}, r.Skipped)
+	cookiestest.RequireNotExists(t, dst+"/link.txt")
+}
+
+func TestCopyDir_DstWithinSrc(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	_, e := cookies.CopyDir(temp, filepath.Join(temp, "dst"), cookies.CopyDirOptions{})
+	require.NotNil(t, e)
+}
+
+func TestCopyDirFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		src := filepath.Join(root, "src")
+		require.Nil(t, cookies.CreateFilesFS(fsys, src, os.ModePerm, map[string][]byte{
+			"abc.txt":        []byte("Weatherwax"),
+			"nested/xyz.txt": []byte("Ogg"),
+		}))
+		require.Nil(t, fsys.Symlink("abc.txt", filepath.Join(src, "link.txt")))
+
+		mtime := time.Date(2019, 4, 15, 21, 50, 33, 0, time.UTC)
+		require.Nil(t, fsys.Chtimes(filepath.Join(src, "abc.txt"), mtime, mtime))
+
+		dst := filepath.Join(root, "dst")
+		r, e := cookies.CopyDirFS(fsys, src, dst, cookies.CopyDirOptions{Copy: cookies.CopyOptions{Atomic: true}})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []string{"abc.txt", "link.txt", "nested/xyz.txt"}, r.Copied)
+
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(dst, "abc.txt"), "Weatherwax")
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(dst, "link.txt"), "Weatherwax")
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(dst, "nested", "xyz.txt"), "Ogg")
+
+		stat, e := fsys.Stat(filepath.Join(dst, "abc.txt"))
+		require.Nil(t, e)
+		require.True(t, mtime.Equal(stat.ModTime()))
+	})
+}
+
+func TestCopyDirContext(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+		require.Nil(t, cookies.CreateFilesFS(fsys, src, os.ModePerm, map[string][]byte{
+			"abc.txt":        []byte("Weatherwax"),
+			"nested/xyz.txt": []byte("Ogg"),
+			"nested/big.txt": []byte(strings.Repeat("Garlick", 10000)),
+		}))
+
+		var calls [][2]int64
+		opts := cookies.CopyDirOptions{Copy: cookies.CopyOptions{
+			Interval: time.Nanosecond,
+			Progress: func(copied, total int64) {
+				calls = append(calls, [2]int64{copied, total})
+			},
+		}}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r, e := cookies.CopyDirContextFS(ctx, fsys, src, dst, opts)
+		require.True(t, errors.Is(e, context.Canceled), "%+v", e)
+		require.Empty(t, r.Copied)
+		require.Empty(t, calls)
+
+		r, e = cookies.CopyDirContextFS(context.Background(), fsys, src, dst, opts)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []string{"abc.txt", "nested/big.txt", "nested/xyz.txt"}, r.Copied)
+
+		const total = 10 + 3 + 70000
+		require.True(t, len(calls) > 1)
+		for i, call := range calls {
+			require.Equal(t, int64(total), call[1])
+			if i > 0 {
+				require.True(t, call[0] >= calls[i-1][0])
+			}
+		}
+		require.Equal(t, [2]int64{total, total}, calls[len(calls)-1])
+	})
+}
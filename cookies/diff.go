@@ -0,0 +1,379 @@
+package cookies
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// DirDiff is the result of comparing two directory trees. Paths are relative
+// to the roots, separated by '/', and sorted.
+type DirDiff struct {
+	OnlyLeft  []string
+	OnlyRight []string
+	Differ    []string
+	Identical []string
+	Diffs     map[string]string // Unified diff, or binary marker, of each differing file
+}
+
+// Equal returns true if the trees hold the same files with the same content.
+func (d DirDiff) Equal() bool {
+	return len(d.OnlyLeft) == 0 && len(d.OnlyRight) == 0 && len(d.Differ) == 0
+}
+
+// String returns a readable report of the differences suitable for test
+// failure messages.
+func (d DirDiff) String() string {
+	if d.Equal() {
+		return fmt.Sprintf("No differences, %d identical file(s)\n", len(d.Identical))
+	}
+
+	sb := strings.Builder{}
+	for _, p := range d.OnlyLeft {
+		sb.WriteString("Only in left: " + p + "\n")
+	}
+	for _, p := range d.OnlyRight {
+		sb.WriteString("Only in right: " + p + "\n")
+	}
+	for _, p := range d.Differ {
+		sb.WriteString(d.Diffs[p])
+	}
+	return sb.String()
+}
+
+// DiffDirs compares the regular files, selected by 'opts', within the
+// directory trees 'left' and 'right'. A unified diff is produced for each
+// differing pair of text files while binary files, those containing a NUL
+// byte or invalid UTF-8, get a 'Binary files ... differ' marker instead.
+func DiffDirs(left, right string, opts WalkOptions) (DirDiff, error) {
+	return DiffDirsFS(OSFS, left, right, opts)
+}
+
+// DiffDirsFS is DiffDirs for any FS.
+func DiffDirsFS(fsys FS, left, right string, opts WalkOptions) (DirDiff, error) {
+
+	d := DirDiff{Diffs: map[string]string{}}
+
+	leftFiles, e := diffDirFiles(fsys, left, opts)
+	if e != nil {
+		return d, e
+	}
+	rightFiles, e := diffDirFiles(fsys, right, opts)
+	if e != nil {
+		return d, e
+	}
+
+	for p := range leftFiles {
+		if !rightFiles[p] {
+			d.OnlyLeft = append(d.OnlyLeft, p)
+			continue
+		}
+
+		a, e := ReadFileFS(fsys, filepath.Join(left, filepath.FromSlash(p)))
+		if e != nil {
+			return d, e
+		}
+		b, e := ReadFileFS(fsys, filepath.Join(right, filepath.FromSlash(p)))
+		if e != nil {
+			return d, e
+		}
+
+		if bytes.Equal(a, b) {
+			d.Identical = append(d.Identical, p)
+			continue
+		}
+
+		d.Differ = append(d.Differ, p)
+		aName := filepath.ToSlash(filepath.Join(left, p))
+		bName := filepath.ToSlash(filepath.Join(right, p))
+
+		if isBinary(a) || isBinary(b) {
+			d.Diffs[p] = fmt.Sprintf("Binary files %s and %s differ\n", aName, bName)
+		} else {
+			d.Diffs[p] = UnifiedDiff(aName, bName, a, b)
+		}
+	}
+
+	for p := range rightFiles {
+		if !leftFiles[p] {
+			d.OnlyRight = append(d.OnlyRight, p)
+		}
+	}
+
+	sort.Strings(d.OnlyLeft)
+	sort.Strings(d.OnlyRight)
+	sort.Strings(d.Differ)
+	sort.Strings(d.Identical)
+	return d, nil
+}
+
+func diffDirFiles(fsys FS, root string, opts WalkOptions) (map[string]bool, error) {
+	opts.Dirs = false
+	files := map[string]bool{}
+
+	e := WalkFS(fsys, root, opts, func(entry WalkEntry) error {
+		if entry.Info.Mode().IsRegular() {
+			files[entry.Rel] = true
+		}
+		return nil
+	})
+
+	return files, e
+}
+
+// isBinary returns true if 'data' contains a NUL byte or is not valid UTF-8.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)
+}
+
+// UnifiedDiff returns the unified diff, as 'diff -u' would produce, of the
+// texts 'a' and 'b' named 'aName' and 'bName' in the header. An empty string
+// is returned if they're the same.
+func UnifiedDiff(aName, bName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+
+	ops := diffLines(splitDiffLines(a), splitDiffLines(b))
+
+	sb := strings.Builder{}
+	sb.WriteString("--- " + aName + "\n")
+	sb.WriteString("+++ " + bName + "\n")
+
+	for _, h := range diffHunks(ops) {
+		writeDiffHunk(&sb, ops, h)
+	}
+
+	return sb.String()
+}
+
+// diffOp is a single line of an edit script: ' ' kept, '-' removed, or '+'
+// added.
+type diffOp struct {
+	kind byte
+	line string // Including its line ending if it has one
+}
+
+func splitDiffLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns an edit script turning 'a' into 'b' using the linear
+// space variant of the Myers diff algorithm. Memory use is proportional to
+// the number of lines, however little 'a' and 'b' have in common.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	diffSpan(&ops, a, b)
+	return ops
+}
+
+// diffSpan appends the edit script turning 'a' into 'b' to 'ops'. Common
+// prefixes and suffixes are kept before the remainder is split at its middle
+// snake and each half diffed in turn.
+func diffSpan(ops *[]diffOp, a, b []string) {
+
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		*ops = append(*ops, diffOp{' ', a[pre]})
+		pre++
+	}
+	a, b = a[pre:], b[pre:]
+
+	suf := 0
+	for suf < len(a) && suf < len(b) && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	common := a[len(a)-suf:]
+	a, b = a[:len(a)-suf], b[:len(b)-suf]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			*ops = append(*ops, diffOp{'+', line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			*ops = append(*ops, diffOp{'-', line})
+		}
+	default:
+		x, y := middleSnake(a, b)
+		diffSpan(ops, a[:x], b[:y])
+		diffSpan(ops, a[x:], b[y:])
+	}
+
+	for _, line := range common {
+		*ops = append(*ops, diffOp{' ', line})
+	}
+}
+
+// middleSnake returns a point, on a shortest edit path from the start of 'a'
+// and 'b' to their ends, found by searching forwards and backwards at the
+// same time until the two searches overlap. 'a' and 'b' must not be empty and
+// must differ in their first and last lines.
+func middleSnake(a, b []string) (int, int) {
+
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	off := maxD
+	size := 2*maxD + 2
+
+	fwd, rev := make([]int, size), make([]int, size)
+	for i := range fwd {
+		fwd[i], rev[i] = -1, -1
+	}
+	fwd[off+1], rev[off+1] = 0, 0
+
+	delta := n - m
+	odd := delta%2 != 0
+	fStart, fEnd, rStart, rEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && fwd[off+k-1] < fwd[off+k+1]) {
+				x = fwd[off+k+1]
+			} else {
+				x = fwd[off+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			fwd[off+k] = x
+
+			switch {
+			case x > n:
+				fEnd += 2 // Off the right of the grid
+			case y > m:
+				fStart += 2 // Off the bottom of the grid
+			case odd:
+				if rk := off + delta - k; rk >= 0 && rk < size && rev[rk] != -1 && x >= n-rev[rk] {
+					return x, y
+				}
+			}
+		}
+
+		for k := -d + rStart; k <= d-rEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && rev[off+k-1] < rev[off+k+1]) {
+				x = rev[off+k+1]
+			} else {
+				x = rev[off+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x, y = x+1, y+1
+			}
+			rev[off+k] = x
+
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !odd:
+				if fk := off + delta - k; fk >= 0 && fk < size && fwd[fk] != -1 {
+					fx := fwd[fk]
+					if fx >= n-x {
+						return fx, off + fx - fk
+					}
+				}
+			}
+		}
+	}
+
+	// Nothing in common, delete all of 'a' then insert all of 'b'
+	return n, 0
+}
+
+// diffHunk is a range of ops, [start, end), printed as one hunk.
+type diffHunk struct {
+	start, end int
+}
+
+// diffHunks groups the changes within 'ops' into hunks with up to
+// diffContext lines of context, merging hunks whose context would overlap.
+func diffHunks(ops []diffOp) []diffHunk {
+	var hunks []diffHunk
+
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+
+		start, end := i-diffContext, i+diffContext+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+			continue
+		}
+		hunks = append(hunks, diffHunk{start, end})
+	}
+
+	return hunks
+}
+
+func writeDiffHunk(sb *strings.Builder, ops []diffOp, h diffHunk) {
+
+	aStart, bStart := 0, 0
+	for _, op := range ops[:h.start] {
+		if op.kind != '+' {
+			aStart++
+		}
+		if op.kind != '-' {
+			bStart++
+		}
+	}
+
+	aCount, bCount := 0, 0
+	for _, op := range ops[h.start:h.end] {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", diffRange(aStart, aCount), diffRange(bStart, bCount))
+
+	for _, op := range ops[h.start:h.end] {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// diffRange formats a hunk range where 'start' is the number of lines before
+// the hunk.
+func diffRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
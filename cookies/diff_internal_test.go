@@ -0,0 +1,63 @@
+package cookies
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffLines(t *testing.T) {
+	t.Parallel()
+	rng := rand.New(rand.NewSource(1))
+
+	randLines := func() []string {
+		var lines []string
+		for i := rng.Intn(12); i > 0; i-- {
+			lines = append(lines, string(rune('a'+rng.Intn(4))))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randLines(), randLines()
+		ops := diffLines(a, b)
+
+		var actA, actB []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				actA = append(actA, op.line)
+			}
+			if op.kind != '-' {
+				actB = append(actB, op.line)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+
+		require.Equal(t, a, actA, "%q -> %q", a, b)
+		require.Equal(t, b, actB, "%q -> %q", a, b)
+		require.Equal(t, len(a)+len(b)-2*lcsLen(a, b), edits, "%q -> %q", a, b)
+	}
+}
+
+// lcsLen returns the length of the longest common subsequence of 'a' and 'b'.
+func lcsLen(a, b []string) int {
+	prev, curr := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				curr[j+1] = prev[j] + 1
+			case prev[j+1] > curr[j]:
+				curr[j+1] = prev[j+1]
+			default:
+				curr[j+1] = curr[j]
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
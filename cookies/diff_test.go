@@ -0,0 +1,138 @@
+package cookies_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n12\n13"
+
+	exp := `--- a.txt
++++ b.txt
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -8,5 +8,5 @@
+ 8
+ 9
+ 10
+-11
+ 12
++13
+\ No newline at end of file
+`
+	require.Equal(t, exp, cookies.UnifiedDiff("a.txt", "b.txt", []byte(a), []byte(b)))
+	require.Equal(t, "", cookies.UnifiedDiff("a.txt", "b.txt", []byte(a), []byte(a)))
+
+	exp = `--- a.txt
++++ b.txt
+@@ -0,0 +1 @@
++Weatherwax
+`
+	require.Equal(t, exp, cookies.UnifiedDiff("a.txt", "b.txt", nil, []byte("Weatherwax\n")))
+}
+
+func TestDiffDirs(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	left, right := filepath.Join(temp, "exp"), filepath.Join(temp, "act")
+	require.Nil(t, cookies.CreateFiles(left, os.ModePerm, map[string][]byte{
+		"same.txt":      []byte("Weatherwax"),
+		"text.txt":      []byte("Esme\nWeatherwax\n"),
+		"image.bin":     {0, 1, 2},
+		"left/only.txt": []byte("Ogg"),
+	}))
+	require.Nil(t, cookies.CreateFiles(right, os.ModePerm, map[string][]byte{
+		"same.txt":  []byte("Weatherwax"),
+		"text.txt":  []byte("Esme\nOgg\n"),
+		"image.bin": {0, 1, 3},
+		"right.txt": []byte("Garlick"),
+	}))
+
+	d, e := cookies.DiffDirs(left, right, cookies.WalkOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.False(t, d.Equal())
+	require.Equal(t, []string{"left/only.txt"}, d.OnlyLeft)
+	require.Equal(t, []string{"right.txt"}, d.OnlyRight)
+	require.Equal(t, []string{"image.bin", "text.txt"}, d.Differ)
+	require.Equal(t, []string{"same.txt"}, d.Identical)
+
+	exp := `Only in left: left/only.txt
+Only in right: right.txt
+Binary files ` + left + `/image.bin and ` + right + `/image.bin differ
+--- ` + left + `/text.txt
++++ ` + right + `/text.txt
+@@ -1,2 +1,2 @@
+ Esme
+-Weatherwax
++Ogg
+`
+	require.Equal(t, filepath.ToSlash(exp), d.String())
+
+	d, e = cookies.DiffDirs(left, left, cookies.WalkOptions{Exclude: []string{"*.bin"}})
+	require.Nil(t, e, "%+v", e)
+	require.True(t, d.Equal())
+	require.Equal(t, "No differences, 3 identical file(s)\n", d.String())
+}
+
+func TestDiffDirsFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		left, right := filepath.Join(root, "exp"), filepath.Join(root, "act")
+		require.Nil(t, cookies.CreateFilesFS(fsys, left, os.ModePerm, map[string][]byte{
+			"same.txt": []byte("Weatherwax"),
+			"text.txt": []byte("Esme\nWeatherwax\n"),
+			"left.txt": []byte("Ogg"),
+		}))
+		require.Nil(t, cookies.CreateFilesFS(fsys, right, os.ModePerm, map[string][]byte{
+			"same.txt": []byte("Weatherwax"),
+			"text.txt": []byte("Esme\nOgg\n"),
+		}))
+
+		d, e := cookies.DiffDirsFS(fsys, left, right, cookies.WalkOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []string{"left.txt"}, d.OnlyLeft)
+		require.Equal(t, []string{"text.txt"}, d.Differ)
+		require.Equal(t, []string{"same.txt"}, d.Identical)
+		require.Contains(t, d.Diffs["text.txt"], "-Weatherwax\n+Ogg\n")
+	})
+}
+
+func TestUnifiedDiff_NothingInCommon(t *testing.T) {
+	a, b := strings.Builder{}, strings.Builder{}
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&a, "Weatherwax %d\n", i)
+		fmt.Fprintf(&b, "Ogg %d\n", i)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	d := cookies.UnifiedDiff("a.txt", "b.txt", []byte(a.String()), []byte(b.String()))
+	runtime.ReadMemStats(&after)
+
+	allocated := after.TotalAlloc - before.TotalAlloc
+	require.True(t, allocated < 64<<20, "Allocated %d bytes", allocated)
+
+	lines := strings.Split(d, "\n")
+	require.Equal(t, "@@ -1,3000 +1,3000 @@", lines[2])
+	require.Equal(t, "-Weatherwax 0", lines[3])
+	require.Equal(t, "+Ogg 0", lines[3003])
+	require.Equal(t, 3+6000+1, len(lines))
+}
@@ -0,0 +1,141 @@
+package cookies
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// DirStack is a goroutine safe alternative to Pushd and Popd that owns its
+// own history. It also notices when the working directory has been changed by
+// something other than the stack. The zero value is an empty stack ready for
+// use.
+//
+// The working directory belongs to the whole process so goroutines sharing a
+// DirStack should use Within, which runs one function at a time, to be
+// protected from each other. Nothing protects them from other DirStacks or
+// code calling os.Chdir directly.
+type DirStack struct {
+	within  sync.Mutex // Held for the whole of each Within call
+	mu      sync.Mutex
+	history []string
+	current string // Working directory the stack expects, empty if none
+}
+
+// DirChangedError is returned when the working directory is not the one a
+// DirStack last changed to.
+type DirChangedError struct {
+	Expected string
+	Actual   string
+}
+
+// Error satisfies the error interface.
+func (e *DirChangedError) Error() string {
+	return fmt.Sprintf("Working directory changed outside of DirStack: expected %s, found %s",
+		e.Expected, e.Actual)
+}
+
+// Pushd emulates the pushd bash command. A DirChangedError is returned, and
+// the working directory left unchanged, if the working directory was changed
+// since the stack last changed it.
+func (s *DirStack) Pushd(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	curr, e := s.check()
+	if e != nil {
+		return e
+	}
+
+	if e = os.Chdir(dir); e != nil {
+		return e
+	}
+
+	if s.current, e = os.Getwd(); e != nil {
+		return e
+	}
+
+	s.history = append(s.history, curr)
+	return nil
+}
+
+// Popd emulates the popd bash command. If the working directory was changed
+// since the stack last changed it then the previous directory is still
+// restored but a DirChangedError is returned.
+func (s *DirStack) Popd() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.history) == 0 {
+		return nil
+	}
+
+	_, changed := s.check()
+
+	last := len(s.history) - 1
+	dir := s.history[last]
+	if e := os.Chdir(dir); e != nil {
+		return e
+	}
+
+	s.history = s.history[:last]
+	s.current = ""
+	if last > 0 {
+		s.current = dir
+	}
+
+	return changed
+}
+
+// Within changes the working directory to 'dir', runs 'f', then restores the
+// previous working directory even if 'f' panics. Calls to Within on the same
+// stack are run one at a time so 'f' always runs within 'dir'. 'f' may use
+// Pushd and Popd but must not call Within on the same stack.
+func (s *DirStack) Within(dir string, f func() error) (e error) {
+	s.within.Lock()
+	defer s.within.Unlock()
+
+	if e = s.Pushd(dir); e != nil {
+		return e
+	}
+
+	defer func() {
+		if popErr := s.Popd(); e == nil {
+			e = popErr
+		}
+	}()
+
+	return f()
+}
+
+// Check returns a DirChangedError if the working directory was changed since
+// the stack last changed it.
+func (s *DirStack) Check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, e := s.check()
+	return e
+}
+
+// History returns a copy of the directories on the stack, the most recent
+// being last.
+func (s *DirStack) History() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.history...)
+}
+
+// check returns the working directory along with a DirChangedError if it's
+// not the one the stack expects.
+func (s *DirStack) check() (string, error) {
+	curr, e := os.Getwd()
+	if e != nil {
+		return "", e
+	}
+
+	if s.current != "" && s.current != curr {
+		return curr, &DirChangedError{Expected: s.current, Actual: curr}
+	}
+
+	return curr, nil
+}
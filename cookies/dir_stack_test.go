@@ -0,0 +1,151 @@
+package cookies_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func requireWorkDir(t *testing.T, exp string) {
+	act, e := os.Getwd()
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, exp, act)
+}
+
+func TestDirStack_Pushd_AND_Popd(t *testing.T) {
+	ws := cookiestest.NewWorkspace(t)
+	ws.Chdir()
+	temp := ws.Root
+
+	a, e := ioutil.TempDir(temp, "a")
+	require.Nil(t, e)
+	b, e := ioutil.TempDir(temp, "b")
+	require.Nil(t, e)
+
+	s := cookies.DirStack{}
+
+	require.Nil(t, s.Pushd(a))
+	requireWorkDir(t, a)
+	require.Equal(t, []string{temp}, s.History())
+
+	require.Nil(t, s.Pushd(b))
+	requireWorkDir(t, b)
+	require.Equal(t, []string{temp, a}, s.History())
+
+	require.Nil(t, s.Popd())
+	requireWorkDir(t, a)
+
+	require.Nil(t, s.Popd())
+	requireWorkDir(t, temp)
+	require.Empty(t, s.History())
+
+	require.Nil(t, s.Popd())
+	requireWorkDir(t, temp)
+}
+
+func TestDirStack_Within(t *testing.T) {
+	ws := cookiestest.NewWorkspace(t)
+	ws.Chdir()
+	temp := ws.Root
+
+	a, e := ioutil.TempDir(temp, "a")
+	require.Nil(t, e)
+
+	s := cookies.DirStack{}
+	exp := errors.New("Octarine")
+
+	e = s.Within(a, func() error {
+		requireWorkDir(t, a)
+		return exp
+	})
+	require.Equal(t, exp, e)
+	requireWorkDir(t, temp)
+
+	require.Panics(t, func() {
+		s.Within(a, func() error {
+			panic("Octarine")
+		})
+	})
+	requireWorkDir(t, temp)
+	require.Empty(t, s.History())
+}
+
+func TestDirStack_Within_Concurrent(t *testing.T) {
+	ws := cookiestest.NewWorkspace(t)
+	ws.Chdir()
+	temp := ws.Root
+
+	s := cookies.DirStack{}
+	start := make(chan struct{})
+	errs := make(chan error, 8)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < cap(errs); i++ {
+		dir, e := ioutil.TempDir(temp, "dir")
+		require.Nil(t, e)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- s.Within(dir, func() error {
+				for j := 0; j < 20; j++ {
+					act, e := os.Getwd()
+					if e != nil {
+						return e
+					}
+					if act != dir {
+						return fmt.Errorf("want %s got %s", dir, act)
+					}
+					runtime.Gosched()
+				}
+				return nil
+			})
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for e := range errs {
+		require.Nil(t, e, "%+v", e)
+	}
+	requireWorkDir(t, temp)
+	require.Empty(t, s.History())
+}
+
+func TestDirStack_Changed(t *testing.T) {
+	ws := cookiestest.NewWorkspace(t)
+	ws.Chdir()
+	temp := ws.Root
+
+	a, e := ioutil.TempDir(temp, "a")
+	require.Nil(t, e)
+	b, e := ioutil.TempDir(temp, "b")
+	require.Nil(t, e)
+
+	s := cookies.DirStack{}
+	require.Nil(t, s.Pushd(a))
+	require.Nil(t, os.Chdir(b))
+
+	e = s.Check()
+	require.IsType(t, &cookies.DirChangedError{}, e)
+	require.Equal(t, a, e.(*cookies.DirChangedError).Expected)
+	require.Equal(t, b, e.(*cookies.DirChangedError).Actual)
+
+	require.IsType(t, &cookies.DirChangedError{}, s.Pushd(temp))
+	requireWorkDir(t, b)
+
+	require.IsType(t, &cookies.DirChangedError{}, s.Popd())
+	requireWorkDir(t, temp)
+	require.Nil(t, s.Check())
+}
@@ -0,0 +1,132 @@
+package cookies
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+)
+
+// EditOptions configures EditLines and ReplaceInFile.
+type EditOptions struct {
+	Backup string // Suffix of a copy of the original file, e.g. ".bak", none if empty
+}
+
+// EditLines applies 'fn' to each line of the text file 'f' returning the
+// number of lines it changed. Lines are passed without their line endings,
+// which are kept as they were so files with '\r\n' or mixed endings stay that
+// way. The file is only rewritten if a line changed, in which case it's
+// replaced atomically, see WriteAtomic, keeping its mode. If 'f' is a symbolic
+// link then the file it points to is edited and the link kept. If opts.Backup
+// is set the original is first copied to 'f' suffixed with it.
+func EditLines(f string, opts EditOptions, fn func(line string) string) (int, error) {
+	return EditLinesFS(OSFS, f, opts, fn)
+}
+
+// EditLinesFS is EditLines for any FS.
+func EditLinesFS(fsys FS, f string, opts EditOptions, fn func(line string) string) (int, error) {
+	return editLines(fsys, f, opts, func(line string) (string, int) {
+		edited := fn(line)
+		if edited == line {
+			return line, 0
+		}
+		return edited, 1
+	})
+}
+
+// ReplaceInFile replaces each match of 're' within each line of the text file
+// 'f' with 'repl', as regexp.ReplaceAllString does, returning the number of
+// replacements that changed the text; a match replaced by identical text
+// isn't counted. It's EditLines otherwise, so line endings are never matched.
+// E.g. bumping a version:
+//
+//	re := regexp.MustCompile(`^(const Version = )".*"$`)
+//	n, e := ReplaceInFile("version.go", re, `$1"1.2.3"`, EditOptions{})
+func ReplaceInFile(f string, re *regexp.Regexp, repl string, opts EditOptions) (int, error) {
+	return ReplaceInFileFS(OSFS, f, re, repl, opts)
+}
+
+// ReplaceInFileFS is ReplaceInFile for any FS.
+func ReplaceInFileFS(fsys FS, f string, re *regexp.Regexp, repl string, opts EditOptions) (int, error) {
+	return editLines(fsys, f, opts, func(line string) (string, int) {
+		edited := re.ReplaceAllString(line, repl)
+		if edited == line {
+			return line, 0
+		}
+
+		n := 0
+		for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+			if string(re.ExpandString(nil, repl, line, m)) != line[m[0]:m[1]] {
+				n++
+			}
+		}
+		return edited, n
+	})
+}
+
+// editLines applies 'fn', which returns the edited line and the number of
+// edits made to it, to each line of the file 'f'.
+func editLines(fsys FS, f string, opts EditOptions, fn func(string) (string, int)) (int, error) {
+
+	// Renaming over a link would replace it rather than edit its target
+	target, e := evalSymlinksFS(fsys, f)
+	if e != nil {
+		return 0, e
+	}
+
+	info, e := fsys.Stat(target)
+	if e != nil {
+		return 0, e
+	}
+
+	data, e := ReadFileFS(fsys, target)
+	if e != nil {
+		return 0, e
+	}
+
+	edited := bytes.Buffer{}
+	edited.Grow(len(data))
+	total := 0
+
+	for rest := data; len(rest) > 0; {
+		line, ending := rest, []byte(nil)
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, rest = rest[:i], rest[i+1:]
+			ending = []byte("\n")
+			if bytes.HasSuffix(line, []byte("\r")) {
+				line, ending = line[:len(line)-1], []byte("\r\n")
+			}
+		} else {
+			rest = nil
+		}
+
+		s, n := fn(string(line))
+		total += n
+		edited.WriteString(s)
+		edited.Write(ending)
+	}
+
+	if total == 0 {
+		return 0, nil
+	}
+
+	mode := info.Mode().Perm()
+	if opts.Backup != "" {
+		e := writeAtomic(fsys, f+opts.Backup, mode, true, func(w io.Writer) error {
+			_, e := w.Write(data)
+			return e
+		})
+		if e != nil {
+			return 0, Wrap(e, "Failed to back up %s", f)
+		}
+	}
+
+	e = writeAtomic(fsys, target, mode, true, func(w io.Writer) error {
+		_, e := edited.WriteTo(w)
+		return e
+	})
+	if e != nil {
+		return 0, e
+	}
+
+	return total, nil
+}
@@ -0,0 +1,135 @@
+package cookies_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditLines(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "abc.txt")
+	require.Nil(t, ioutil.WriteFile(f, []byte("weatherwax\r\nogg\ngarlick"), 0640))
+	require.Nil(t, os.Chmod(f, 0640))
+
+	n, e := cookies.EditLines(f, cookies.EditOptions{Backup: ".bak"}, func(line string) string {
+		if line == "ogg" {
+			return line
+		}
+		return strings.ToUpper(line[:1]) + line[1:]
+	})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 2, n)
+
+	cookiestest.RequireFile(t, f, "Weatherwax\r\nogg\nGarlick")
+	cookiestest.RequireFile(t, f+".bak", "weatherwax\r\nogg\ngarlick")
+
+	for _, p := range []string{f, f + ".bak"} {
+		stat, e := os.Stat(p)
+		require.Nil(t, e)
+		require.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+	}
+
+	require.Nil(t, os.Remove(f+".bak"))
+	n, e = cookies.EditLines(f, cookies.EditOptions{Backup: ".bak"}, func(line string) string {
+		return line
+	})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 0, n)
+	cookiestest.RequireNotExists(t, f+".bak")
+
+	_, e = cookies.EditLines(filepath.Join(temp, "missing.txt"), cookies.EditOptions{}, strings.ToUpper)
+	require.NotNil(t, e)
+}
+
+func TestReplaceInFile(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "version.go")
+	require.Nil(t, ioutil.WriteFile(f, []byte("package main\r\n\r\nconst Version = \"1.0.0\"\r\n// 1.0 1.0\r\n"), 0666))
+
+	re := regexp.MustCompile(`^(const Version = )".*"$`)
+	n, e := cookies.ReplaceInFile(f, re, `$1"1.2.3"`, cookies.EditOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 1, n)
+	cookiestest.RequireFile(t, f, "package main\r\n\r\nconst Version = \"1.2.3\"\r\n// 1.0 1.0\r\n")
+
+	n, e = cookies.ReplaceInFile(f, regexp.MustCompile(`1\.0`), "2.0", cookies.EditOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 2, n)
+	cookiestest.RequireFile(t, f, "package main\r\n\r\nconst Version = \"1.2.3\"\r\n// 2.0 2.0\r\n")
+}
+
+func TestEditLinesFS_AND_ReplaceInFileFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		f := filepath.Join(root, "abc.txt")
+		require.Nil(t, fsys.MkdirAll(root, os.ModePerm))
+		require.Nil(t, cookies.WriteFileFS(fsys, f, []byte("weatherwax\r\nogg\n"), 0640))
+
+		n, e := cookies.EditLinesFS(fsys, f, cookies.EditOptions{Backup: ".bak"}, strings.ToUpper)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, 2, n)
+		cookiestest.RequireFileFS(t, fsys, f, "WEATHERWAX\r\nOGG\n")
+		cookiestest.RequireFileFS(t, fsys, f+".bak", "weatherwax\r\nogg\n")
+
+		n, e = cookies.ReplaceInFileFS(fsys, f, regexp.MustCompile(`G`), "g", cookies.EditOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, 2, n)
+		cookiestest.RequireFileFS(t, fsys, f, "WEATHERWAX\r\nOgg\n")
+
+		stat, e := fsys.Stat(f)
+		require.Nil(t, e)
+		require.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+	})
+}
+
+func TestEditLinesFS_Symlink(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		f, link := filepath.Join(root, "abc.txt"), filepath.Join(root, "link.txt")
+		require.Nil(t, fsys.MkdirAll(root, os.ModePerm))
+		require.Nil(t, cookies.WriteFileFS(fsys, f, []byte("weatherwax\n"), 0666))
+		require.Nil(t, fsys.Symlink(f, link))
+
+		n, e := cookies.EditLinesFS(fsys, link, cookies.EditOptions{}, strings.ToUpper)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, 1, n)
+		cookiestest.RequireFileFS(t, fsys, f, "WEATHERWAX\n")
+
+		info, e := fsys.Lstat(link)
+		require.Nil(t, e, "%+v", e)
+		require.True(t, info.Mode()&os.ModeSymlink != 0)
+	})
+}
+
+func TestReplaceInFileFS_Unchanged(t *testing.T) {
+	t.Parallel()
+
+	m := cookies.NewMemFS()
+	require.Nil(t, cookies.WriteFileFS(m, "/abc.txt", []byte("Weatherwax Ogg\n"), 0666))
+	m.Fail("rename", "", errors.New("Written but unchanged"))
+
+	n, e := cookies.ReplaceInFileFS(m, "/abc.txt", regexp.MustCompile(`Ogg`), "Ogg", cookies.EditOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 0, n)
+
+	n, e = cookies.ReplaceInFileFS(m, "/abc.txt", regexp.MustCompile(`(\w+) (\w+)`), "$1 Ogg", cookies.EditOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 0, n)
+
+	m.Fail("rename", "", nil)
+	n, e = cookies.ReplaceInFileFS(m, "/abc.txt", regexp.MustCompile(`\w+`), "Ogg", cookies.EditOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 1, n)
+	cookiestest.RequireFileFS(t, m, "/abc.txt", "Ogg Ogg\n")
+}
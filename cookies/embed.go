@@ -0,0 +1,281 @@
+package cookies
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EmbedStyle specifies how GenerateEmbed declares the embedded files.
+type EmbedStyle int
+
+const (
+	EmbedMap  EmbedStyle = iota // A single map of relative path to content
+	EmbedVars                   // A variable per file named after its path
+)
+
+// EmbedOptions configures GenerateEmbed.
+type EmbedOptions struct {
+	Package  string     // Package name of the generated file, required
+	Style    EmbedStyle // How the files are declared
+	Name     string     // Map name or variable prefix, "Files" if empty
+	Gzip     bool       // Compress the content, decoded via the accessor
+	Accessor string     // Name of the decoding function, "Gunzip" if empty
+}
+
+// GenerateEmbed returns a gofmt formatted Go source file embedding the
+// content of the files within the directory 'root' that match any of the
+// 'inputs'. Inputs are MatchGlob patterns relative to 'root' so plain file
+// paths are matched literally. Each input must match at least one file.
+//
+// With the EmbedMap style a map[string][]byte is declared using the '/'
+// separated relative paths as keys. With EmbedVars a []byte variable is
+// declared per file named by joining opts.Name with the title cased words of
+// its path, e.g. 'static/index.html' becomes 'FilesStaticIndexHtml'.
+//
+// If opts.Gzip is set the content is gzipped and a function with the
+// signature 'func(data []byte) ([]byte, error)' is declared to decode it.
+//
+// The output only depends on the inputs so generating twice from the same
+// files produces identical source.
+func GenerateEmbed(root string, inputs []string, opts EmbedOptions) ([]byte, error) {
+	return GenerateEmbedFS(OSFS, root, inputs, opts)
+}
+
+// GenerateEmbedFS is GenerateEmbed for any FS.
+func GenerateEmbedFS(fsys FS, root string, inputs []string, opts EmbedOptions) ([]byte, error) {
+
+	if opts.Name == "" {
+		opts.Name = "Files"
+	}
+	if opts.Accessor == "" {
+		opts.Accessor = "Gunzip"
+	}
+	for _, id := range []string{opts.Package, opts.Name, opts.Accessor} {
+		if !token.IsIdentifier(id) {
+			return nil, fmt.Errorf("Bad identifier: %q", id)
+		}
+	}
+
+	files, e := embedFiles(fsys, root, inputs)
+	if e != nil {
+		return nil, e
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("// Code generated by cookies.GenerateEmbed. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %s\n\n", opts.Package)
+
+	if opts.Gzip {
+		buf.WriteString(embedGzipImports)
+	}
+
+	if opts.Style == EmbedVars {
+		e = writeEmbedVars(buf, fsys, root, files, opts)
+	} else {
+		e = writeEmbedMap(buf, fsys, root, files, opts)
+	}
+	if e != nil {
+		return nil, e
+	}
+
+	if opts.Gzip {
+		fmt.Fprintf(buf, embedGzipAccessor, opts.Accessor, opts.Accessor)
+	}
+
+	src, e := format.Source(buf.Bytes())
+	if e != nil {
+		return nil, Wrap(e, "Generated source is invalid")
+	}
+	return src, nil
+}
+
+// WriteEmbed generates the embedding source, as GenerateEmbed does, and
+// atomically writes it to the file 'dst'.
+func WriteEmbed(dst, root string, inputs []string, opts EmbedOptions) error {
+	return WriteEmbedFS(OSFS, dst, root, inputs, opts)
+}
+
+// WriteEmbedFS is WriteEmbed for any FS.
+func WriteEmbedFS(fsys FS, dst, root string, inputs []string, opts EmbedOptions) error {
+	src, e := GenerateEmbedFS(fsys, root, inputs, opts)
+	if e != nil {
+		return e
+	}
+	return WriteFileAtomicFS(fsys, dst, src, 0666)
+}
+
+// CheckEmbed returns true if the file 'dst' is stale, i.e. it doesn't exist or
+// differs from the source GenerateEmbed would produce for the same inputs.
+func CheckEmbed(dst, root string, inputs []string, opts EmbedOptions) (bool, error) {
+	return CheckEmbedFS(OSFS, dst, root, inputs, opts)
+}
+
+// CheckEmbedFS is CheckEmbed for any FS.
+func CheckEmbedFS(fsys FS, dst, root string, inputs []string, opts EmbedOptions) (bool, error) {
+	src, e := GenerateEmbedFS(fsys, root, inputs, opts)
+	if e != nil {
+		return false, e
+	}
+
+	existing, e := ReadFileFS(fsys, dst)
+	if os.IsNotExist(e) {
+		return true, nil
+	}
+	if e != nil {
+		return false, e
+	}
+
+	return !bytes.Equal(src, existing), nil
+}
+
+// embedFiles returns the relative paths, in lexical order, of the files
+// within 'root' matching any of the 'inputs'.
+func embedFiles(fsys FS, root string, inputs []string) ([]string, error) {
+
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("No input files specified")
+	}
+
+	var files []string
+	matched := make([]bool, len(inputs))
+
+	e := WalkFS(fsys, root, WalkOptions{Patterns: inputs, FollowSymlinks: true}, func(entry WalkEntry) error {
+		if !entry.Info.Mode().IsRegular() {
+			return nil
+		}
+		for i, p := range inputs {
+			if ok, _ := MatchGlob(p, entry.Rel); ok {
+				matched[i] = true
+			}
+		}
+		files = append(files, entry.Rel)
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	for i, ok := range matched {
+		if !ok {
+			return nil, fmt.Errorf("No files match input %q in %s", inputs[i], root)
+		}
+	}
+	return files, nil
+}
+
+// embedQuote returns the content of the file 'rel', gzipped if requested, as
+// a quoted string.
+func embedQuote(fsys FS, root, rel string, gz bool) (string, error) {
+	f := filepath.Join(root, filepath.FromSlash(rel))
+	if !gz {
+		return FileToQuoteFS(fsys, f)
+	}
+
+	data, e := ReadFileFS(fsys, f)
+	if e != nil {
+		return "", e
+	}
+
+	buf := &bytes.Buffer{}
+	w, _ := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	if _, e := w.Write(data); e != nil {
+		return "", e
+	}
+	if e := w.Close(); e != nil {
+		return "", e
+	}
+
+	return strconv.Quote(buf.String()), nil
+}
+
+func writeEmbedMap(buf *bytes.Buffer, fsys FS, root string, files []string, opts EmbedOptions) error {
+
+	fmt.Fprintf(buf, "// %s maps the relative path of each embedded file to its content", opts.Name)
+	if opts.Gzip {
+		fmt.Fprintf(buf, ",\n// decode the content using %s", opts.Accessor)
+	}
+	fmt.Fprintf(buf, ".\nvar %s = map[string][]byte{\n", opts.Name)
+
+	for _, rel := range files {
+		q, e := embedQuote(fsys, root, rel, opts.Gzip)
+		if e != nil {
+			return e
+		}
+		fmt.Fprintf(buf, "%s: []byte(%s),\n", strconv.Quote(rel), q)
+	}
+
+	buf.WriteString("}\n\n")
+	return nil
+}
+
+func writeEmbedVars(buf *bytes.Buffer, fsys FS, root string, files []string, opts EmbedOptions) error {
+
+	names := map[string]string{}
+	buf.WriteString("var (\n")
+
+	for _, rel := range files {
+		name := embedVarName(opts.Name, rel)
+		if prev, ok := names[name]; ok {
+			return fmt.Errorf("Files %q and %q have the same variable name %s", prev, rel, name)
+		}
+		names[name] = rel
+
+		q, e := embedQuote(fsys, root, rel, opts.Gzip)
+		if e != nil {
+			return e
+		}
+		fmt.Fprintf(buf, "%s = []byte(%s) // %s\n", name, q, rel)
+	}
+
+	buf.WriteString(")\n\n")
+	return nil
+}
+
+// embedVarName returns the variable name for the file 'rel' by joining
+// 'prefix' with the title cased words of the path.
+func embedVarName(prefix, rel string) string {
+	sb := strings.Builder{}
+	sb.WriteString(prefix)
+
+	upper := true
+	for _, ru := range rel {
+		switch {
+		case !unicode.IsLetter(ru) && !unicode.IsDigit(ru):
+			upper = true
+		case upper:
+			sb.WriteRune(unicode.ToUpper(ru))
+			upper = false
+		default:
+			sb.WriteRune(ru)
+		}
+	}
+
+	return sb.String()
+}
+
+const embedGzipImports = `import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+)
+
+`
+
+const embedGzipAccessor = `// %s decompresses the gzipped content of an embedded file.
+func %s(data []byte) ([]byte, error) {
+	r, e := gzip.NewReader(bytes.NewReader(data))
+	if e != nil {
+		return nil, e
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+`
@@ -0,0 +1,163 @@
+package cookies_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+// parseEmbed parses the generated source 'src' returning the string literals
+// assigned to each variable or map key.
+func parseEmbed(t *testing.T, src []byte) map[string]string {
+	f, e := parser.ParseFile(token.NewFileSet(), "embed.go", src, 0)
+	require.Nil(t, e, "%+v\n%s", e, src)
+
+	lits := map[string]string{}
+	unquote := func(expr ast.Expr) string {
+		if call, ok := expr.(*ast.CallExpr); ok {
+			expr = call.Args[0]
+		}
+		s, e := strconv.Unquote(expr.(*ast.BasicLit).Value)
+		require.Nil(t, e)
+		return s
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.KeyValueExpr:
+			lits[unquote(n.Key)] = unquote(n.Value)
+		case *ast.ValueSpec:
+			if _, ok := n.Values[0].(*ast.CallExpr); ok {
+				lits[n.Names[0].Name] = unquote(n.Values[0])
+			}
+		}
+		return true
+	})
+
+	return lits
+}
+
+func TestGenerateEmbed(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		"static/index.html": []byte("<p>Weatherwax</p>"),
+		"static/css/a.css":  []byte("p {}"),
+		"README.md":         []byte("# Ogg\n"),
+		"other.txt":         []byte("Garlick"),
+	}))
+
+	src, e := cookies.GenerateEmbed(temp, []string{"static/**", "README.md"}, cookies.EmbedOptions{
+		Package: "assets",
+	})
+	require.Nil(t, e, "%+v", e)
+	require.Contains(t, string(src), "package assets")
+	require.Contains(t, string(src), "var Files = map[string][]byte{")
+	require.Equal(t, map[string]string{
+		"README.md":         "# Ogg\n",
+		"static/css/a.css":  "p {}",
+		"static/index.html": "<p>Weatherwax</p>",
+	}, parseEmbed(t, src))
+
+	src, e = cookies.GenerateEmbed(temp, []string{"static/index.html", "other.txt"}, cookies.EmbedOptions{
+		Package: "assets",
+		Style:   cookies.EmbedVars,
+		Name:    "Asset",
+	})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, map[string]string{
+		"AssetOtherTxt":        "Garlick",
+		"AssetStaticIndexHtml": "<p>Weatherwax</p>",
+	}, parseEmbed(t, src))
+
+	_, e = cookies.GenerateEmbed(temp, []string{"missing.txt"}, cookies.EmbedOptions{Package: "assets"})
+	require.NotNil(t, e)
+
+	_, e = cookies.GenerateEmbed(temp, []string{"other.txt"}, cookies.EmbedOptions{Package: "bad-name"})
+	require.NotNil(t, e)
+}
+
+func TestGenerateEmbed_Gzip(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		"abc.txt": []byte("Weatherwax Weatherwax Weatherwax"),
+	}))
+
+	src, e := cookies.GenerateEmbed(temp, []string{"*.txt"}, cookies.EmbedOptions{
+		Package:  "assets",
+		Gzip:     true,
+		Accessor: "Decode",
+	})
+	require.Nil(t, e, "%+v", e)
+	require.Contains(t, string(src), "func Decode(data []byte) ([]byte, error) {")
+
+	r, e := gzip.NewReader(bytes.NewReader([]byte(parseEmbed(t, src)["abc.txt"])))
+	require.Nil(t, e, "%+v", e)
+	act, e := ioutil.ReadAll(r)
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, "Weatherwax Weatherwax Weatherwax", string(act))
+}
+
+func TestCheckEmbed(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	in := filepath.Join(temp, "in")
+	require.Nil(t, cookies.CreateFiles(in, os.ModePerm, map[string][]byte{
+		"abc.txt": []byte("Weatherwax"),
+	}))
+
+	dst := filepath.Join(temp, "embed.go")
+	opts := cookies.EmbedOptions{Package: "assets", Gzip: true}
+	inputs := []string{"abc.txt"}
+
+	stale, e := cookies.CheckEmbed(dst, in, inputs, opts)
+	require.Nil(t, e, "%+v", e)
+	require.True(t, stale)
+
+	require.Nil(t, cookies.WriteEmbed(dst, in, inputs, opts))
+	stale, e = cookies.CheckEmbed(dst, in, inputs, opts)
+	require.Nil(t, e, "%+v", e)
+	require.False(t, stale)
+
+	require.Nil(t, ioutil.WriteFile(in+"/abc.txt", []byte("Ogg"), 0666))
+	stale, e = cookies.CheckEmbed(dst, in, inputs, opts)
+	require.Nil(t, e, "%+v", e)
+	require.True(t, stale)
+}
+
+func TestGenerateEmbedFS_AND_CheckEmbedFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		in := filepath.Join(root, "in")
+		require.Nil(t, cookies.CreateFilesFS(fsys, in, os.ModePerm, map[string][]byte{
+			"abc.txt": []byte("Weatherwax"),
+		}))
+
+		opts := cookies.EmbedOptions{Package: "assets"}
+		inputs := []string{"abc.txt"}
+
+		src, e := cookies.GenerateEmbedFS(fsys, in, inputs, opts)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, map[string]string{"abc.txt": "Weatherwax"}, parseEmbed(t, src))
+
+		dst := filepath.Join(root, "embed.go")
+		require.Nil(t, cookies.WriteEmbedFS(fsys, dst, in, inputs, opts))
+		cookiestest.RequireFileFS(t, fsys, dst, string(src))
+
+		stale, e := cookies.CheckEmbedFS(fsys, dst, in, inputs, opts)
+		require.Nil(t, e, "%+v", e)
+		require.False(t, stale)
+	})
+}
@@ -0,0 +1,21 @@
+package cookies
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Hooks into unexported state for the external cookies_test package.
+
+// SetClock replaces the clock used for time based rotation, restarting the
+// current interval.
+func (w *RotateWriter) SetClock(now func() time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.now, w.opened = now, now()
+}
+
+// Polls returns the number of polls the watcher has completed.
+func (w *Watcher) Polls() int32 {
+	return atomic.LoadInt32(&w.polls)
+}
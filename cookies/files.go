@@ -3,7 +3,6 @@ package cookies
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -11,7 +10,7 @@ import (
 )
 
 // WorkDirHistory is the working directory stack used by Pushd and Popd
-// functions.
+// functions. It is not goroutine safe, use a DirStack when that matters.
 var WorkDirHistory = []string{}
 
 // Pushd emulates pushd bash command.
@@ -44,7 +43,12 @@ func Popd() error {
 // FileExists returns true if the file exists, false if not, and an error if
 // file existence could not be determined.
 func FileExists(f string) (bool, error) {
-	_, e := os.Stat(f)
+	return FileExistsFS(OSFS, f)
+}
+
+// FileExistsFS is FileExists for any FS.
+func FileExistsFS(fsys FS, f string) (bool, error) {
+	_, e := fsys.Stat(f)
 	if os.IsNotExist(e) {
 		return false, nil
 	}
@@ -54,7 +58,12 @@ func FileExists(f string) (bool, error) {
 // IsDir returns true if the file exists and is a directory. An error is
 // returned if this could not be determined.
 func IsDir(f string) (bool, error) {
-	stat, e := os.Stat(f)
+	return IsDirFS(OSFS, f)
+}
+
+// IsDirFS is IsDir for any FS.
+func IsDirFS(fsys FS, f string) (bool, error) {
+	stat, e := fsys.Stat(f)
 	if os.IsNotExist(e) {
 		return false, nil
 	}
@@ -67,7 +76,12 @@ func IsDir(f string) (bool, error) {
 // IsRegFile returns true if the file exists and is a regular file. An error is
 // returned if this could not be determined.
 func IsRegFile(f string) (bool, error) {
-	stat, e := os.Stat(f)
+	return IsRegFileFS(OSFS, f)
+}
+
+// IsRegFileFS is IsRegFile for any FS.
+func IsRegFileFS(fsys FS, f string) (bool, error) {
+	stat, e := fsys.Stat(f)
 	if os.IsNotExist(e) {
 		return false, nil
 	}
@@ -81,26 +95,82 @@ func IsRegFile(f string) (bool, error) {
 // as determined by os.SameFile. An error is returned if the file info could
 // not be retreived for either file.
 func SameFile(a, b string) (bool, error) {
-	aStat, e := os.Stat(a)
+	return SameFileFS(OSFS, a, b)
+}
+
+// SameFileFS is SameFile for any FS. Files within a MemFS are the same if
+// they are the same node.
+func SameFileFS(fsys FS, a, b string) (bool, error) {
+	aStat, e := fsys.Stat(a)
 	if e != nil {
 		return false, e
 	}
-	bStat, e := os.Stat(b)
+	bStat, e := fsys.Stat(b)
 	if e != nil {
 		return false, e
 	}
-	return os.SameFile(aStat, bStat), nil
+	return sameFileInfo(aStat, bStat), nil
+}
+
+// sameFileInfo is os.SameFile extended to MemFS files.
+func sameFileInfo(a, b os.FileInfo) bool {
+	if n, ok := a.Sys().(*memNode); ok {
+		return n == b.Sys()
+	}
+	return os.SameFile(a, b)
 }
 
-// CopyFile copies the single file 'src' to 'dst'.
+// CopyFile copies the single file 'src' to 'dst'. Use CopyFileWith for
+// atomic copies.
 func CopyFile(src, dst string, overwrite bool) error {
+	return CopyFileFS(OSFS, src, dst, overwrite)
+}
+
+// CopyFileFS is CopyFile for any FS.
+func CopyFileFS(fsys FS, src, dst string, overwrite bool) error {
+	return CopyFileWithFS(fsys, src, dst, CopyFileOptions{Overwrite: overwrite})
+}
+
+// CopyFileOptions configures CopyFileWith.
+type CopyFileOptions struct {
+	Overwrite bool // Replace an existing destination
+	Atomic    bool // Write using WriteAtomic, an existing destination keeps its mode
+}
+
+// CopyFileWith is CopyFile configured by 'opts'.
+func CopyFileWith(src, dst string, opts CopyFileOptions) error {
+	return CopyFileWithFS(OSFS, src, dst, opts)
+}
+
+// CopyFileWithFS is CopyFileWith for any FS.
+func CopyFileWithFS(fsys FS, src, dst string, opts CopyFileOptions) error {
+	if e := checkCopyFile(fsys, src, dst, opts.Overwrite); e != nil {
+		return e
+	}
+	if !opts.Atomic {
+		return NoCheckCopyFileFS(fsys, src, dst)
+	}
+
+	srcFile, e := fsys.Open(src)
+	if e != nil {
+		return e
+	}
+	defer srcFile.Close()
 
-	if ok, e := IsRegFile(src); e != nil || !ok {
+	return replaceAtomic(fsys, dst, func(w io.Writer) error {
+		_, e := io.Copy(w, srcFile)
+		return e
+	})
+}
+
+func checkCopyFile(fsys FS, src, dst string, overwrite bool) error {
+
+	if ok, e := IsRegFileFS(fsys, src); e != nil || !ok {
 		return fmt.Errorf("Missing or not a regular file: %s", src)
 	}
 
 	if !overwrite {
-		ok, e := FileExists(dst)
+		ok, e := FileExistsFS(fsys, dst)
 		if e != nil {
 			return e
 		}
@@ -109,42 +179,51 @@ func CopyFile(src, dst string, overwrite bool) error {
 		}
 	}
 
-	same, e := SameFile(src, dst)
+	same, e := SameFileFS(fsys, src, dst)
 	if e == nil && same {
 		return fmt.Errorf("Destination is the same as source: %s == %s", dst, src)
 	}
 
-	return NoCheckCopyFile(src, dst)
+	return nil
 }
 
 // NoCheckCopyFile copies the single file 'src' to 'dst' and doesn't make any
 // attempt to check the file paths before hand.
 func NoCheckCopyFile(src, dst string) error {
+	return NoCheckCopyFileFS(OSFS, src, dst)
+}
+
+// NoCheckCopyFileFS is NoCheckCopyFile for any FS.
+func NoCheckCopyFileFS(fsys FS, src, dst string) error {
 
-	srcFile, e := os.Open(src)
+	srcFile, e := fsys.Open(src)
 	if e != nil {
 		return e
 	}
 	defer srcFile.Close()
 
-	dstFile, e := os.Create(dst)
+	dstFile, e := fsys.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if e != nil {
 		return e
 	}
-	defer dstFile.Close()
 
-	_, e = io.Copy(dstFile, srcFile)
-	if e != nil {
+	if _, e = io.Copy(dstFile, srcFile); e != nil {
+		dstFile.Close()
 		return e
 	}
 
-	return nil
+	return dstFile.Close()
 }
 
 // FileToQuote returns the bytes of the input file as as a quoted string so it
 // may be embedded in source code. Use []byte(quotedString) to decode.
 func FileToQuote(file string) (string, error) {
-	b, e := ioutil.ReadFile(file)
+	return FileToQuoteFS(OSFS, file)
+}
+
+// FileToQuoteFS is FileToQuote for any FS.
+func FileToQuoteFS(fsys FS, file string) (string, error) {
+	b, e := ReadFileFS(fsys, file)
 	if e != nil {
 		return "", e
 	}
@@ -155,26 +234,60 @@ func FileToQuote(file string) (string, error) {
 // CreateFiles creates the files and directories within 'files' with 'root' as
 // the root directory. 'files' contains a set of relative file paths mapped to
 // the their required content. If the file is a directory it must be suffixed
-// with a '/' and the mapped data will be ignored.
+// with a '/' and the mapped data will be ignored. An error wrapping
+// ErrPathEscapes is returned if any path, including via existing symbolic
+// links, would lead outside of 'root'.
 func CreateFiles(root string, mode os.FileMode, files map[string][]byte) error {
+	return CreateFilesFS(OSFS, root, mode, files)
+}
+
+// CreateFilesFS is CreateFiles for any FS.
+func CreateFilesFS(fsys FS, root string, mode os.FileMode, files map[string][]byte) error {
+	return createFiles(fsys, root, mode, files, WriteFileFS)
+}
+
+// CreateOptions configures CreateFilesWith.
+type CreateOptions struct {
+	Atomic bool // Write each file using WriteFileAtomic
+}
+
+// CreateFilesWith is CreateFiles configured by 'opts'.
+func CreateFilesWith(root string, mode os.FileMode, files map[string][]byte, opts CreateOptions) error {
+	return CreateFilesWithFS(OSFS, root, mode, files, opts)
+}
+
+// CreateFilesWithFS is CreateFilesWith for any FS.
+func CreateFilesWithFS(fsys FS, root string, mode os.FileMode, files map[string][]byte, opts CreateOptions) error {
+	if opts.Atomic {
+		return createFiles(fsys, root, mode, files, WriteFileAtomicFS)
+	}
+	return createFiles(fsys, root, mode, files, WriteFileFS)
+}
+
+type writeFileFunc func(fsys FS, f string, data []byte, mode os.FileMode) error
+
+func createFiles(fsys FS, root string, mode os.FileMode, files map[string][]byte, write writeFileFunc) error {
 
 	createFile := func(f string, data []byte) error {
 		parent := filepath.Dir(f)
-		if e := os.MkdirAll(parent, mode); e != nil { // Create parents if missing
+		if e := fsys.MkdirAll(parent, mode); e != nil { // Create parents if missing
 			return e
 		}
-		return ioutil.WriteFile(f, data, mode)
+		return write(fsys, f, data, mode)
 	}
 
 	createDir := func(d string) error {
-		if exists, e := FileExists(d); e != nil || exists {
+		if exists, e := FileExistsFS(fsys, d); e != nil || exists {
 			return e
 		}
-		return os.MkdirAll(d, mode)
+		return fsys.MkdirAll(d, mode)
 	}
 
 	for p, data := range files {
-		f := filepath.Join(root, p)
+		f, e := SafeResolveFS(fsys, root, p)
+		if e != nil {
+			return e
+		}
 
 		if strings.HasSuffix(p, "/") {
 			if e := createDir(f); e != nil {
@@ -1,57 +1,21 @@
-package cookies
+package cookies_test
 
 import (
+	"errors"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
 	"github.com/stretchr/testify/require"
 )
 
-func startFileTest() (home, temp string) {
-	var e error
-	if home, e = os.Getwd(); e != nil {
-		panic(e)
-	}
-	if temp, e = filepath.Abs("."); e != nil {
-		panic(e)
-	}
-	if temp, e = ioutil.TempDir(temp, "*"); e != nil {
-		panic(e)
-	}
-	if e = os.Chdir(temp); e != nil {
-		panic(e)
-	}
-	return home, temp
-}
-
-func endFileTest(home, temp string) {
-	if e := os.Chdir(home); e != nil {
-		panic(e)
-	}
-	if e := os.RemoveAll(temp); e != nil {
-		panic(e)
-	}
-}
-
-func requireFile(t *testing.T, f string, exp string) {
-	require.FileExists(t, f)
-	bytes, e := ioutil.ReadFile(f)
-	require.Nil(t, e, "%+v", e)
-	require.Equal(t, exp, string(bytes))
-}
-
-func requireNotExists(t *testing.T, f string) {
-	_, e := os.Stat(f)
-	require.NotNil(t, e, "File still exists: %s", f)
-	require.True(t, os.IsNotExist(e), "File still exists: %s", f)
-}
-
 func TestPushd_AND_Popd(t *testing.T) {
-	home, temp := startFileTest()
-	defer endFileTest(home, temp)
+	ws := cookiestest.NewWorkspace(t)
+	ws.Chdir()
+	temp := ws.Root
 
 	tempDir := func(dir string) string {
 		r, e := ioutil.TempDir(temp, dir)
@@ -62,71 +26,158 @@ func TestPushd_AND_Popd(t *testing.T) {
 	}
 
 	requireHistory := func(exps ...string) {
-		require.Equal(t, len(exps), len(WorkDirHistory))
+		require.Equal(t, len(exps), len(cookies.WorkDirHistory))
 		for i, exp := range exps {
-			require.Equal(t, exp, WorkDirHistory[i])
+			require.Equal(t, exp, cookies.WorkDirHistory[i])
 		}
 	}
 
 	a := tempDir("a")
-	require.Nil(t, Pushd(a))
+	require.Nil(t, cookies.Pushd(a))
 	requireHistory(temp)
 
 	b := tempDir("b")
-	require.Nil(t, Pushd(b))
+	require.Nil(t, cookies.Pushd(b))
 	requireHistory(temp, a)
 
 	c := tempDir("c")
-	require.Nil(t, Pushd(c))
+	require.Nil(t, cookies.Pushd(c))
 	requireHistory(temp, a, b)
 
-	require.Nil(t, Popd())
+	require.Nil(t, cookies.Popd())
 	requireHistory(temp, a)
 
-	require.Nil(t, Popd())
+	require.Nil(t, cookies.Popd())
 	requireHistory(temp)
 
-	require.Nil(t, Popd())
+	require.Nil(t, cookies.Popd())
 	requireHistory()
 }
 
-func TestFileToQuote(t *testing.T) {
-	home, temp := startFileTest()
-	defer endFileTest(home, temp)
+func TestFileExists_AND_IsDir_AND_IsRegFile(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"abc.txt": []byte("Weatherwax"),
+			"empty/":  nil,
+		}))
+
+		requireResult := func(exp bool, f func(cookies.FS, string) (bool, error), p string) {
+			act, e := f(fsys, filepath.Join(root, p))
+			require.Nil(t, e, "%+v", e)
+			require.Equal(t, exp, act, p)
+		}
 
-	f, e := ioutil.TempFile(temp, "*")
-	if e != nil {
-		log.Fatal(e)
-	}
+		requireResult(true, cookies.FileExistsFS, "abc.txt")
+		requireResult(true, cookies.FileExistsFS, "empty")
+		requireResult(false, cookies.FileExistsFS, "xyz.txt")
 
-	data := []byte("What you see is all there is.")
-	if _, e = f.Write(data); e != nil {
-		log.Fatal(e)
-	}
+		requireResult(false, cookies.IsDirFS, "abc.txt")
+		requireResult(true, cookies.IsDirFS, "empty")
+		requireResult(false, cookies.IsDirFS, "xyz.txt")
+
+		requireResult(true, cookies.IsRegFileFS, "abc.txt")
+		requireResult(false, cookies.IsRegFileFS, "empty")
+		requireResult(false, cookies.IsRegFileFS, "xyz.txt")
+	})
+}
+
+func TestSameFile(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		abc, xyz := filepath.Join(root, "abc.txt"), filepath.Join(root, "xyz.txt")
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"abc.txt": []byte("Weatherwax"),
+			"xyz.txt": []byte("Weatherwax"),
+		}))
+		require.Nil(t, fsys.Symlink(abc, filepath.Join(root, "link.txt")))
+
+		same, e := cookies.SameFileFS(fsys, abc, filepath.Join(root, "link.txt"))
+		require.Nil(t, e, "%+v", e)
+		require.True(t, same)
+
+		same, e = cookies.SameFileFS(fsys, abc, xyz)
+		require.Nil(t, e, "%+v", e)
+		require.False(t, same)
+
+		_, e = cookies.SameFileFS(fsys, abc, filepath.Join(root, "missing.txt"))
+		require.NotNil(t, e)
+	})
+}
 
-	a, e := FileToQuote(f.Name())
-	require.Nil(t, e)
+func TestCopyFile(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		src, dst := filepath.Join(root, "src.txt"), filepath.Join(root, "dst.txt")
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"src.txt": []byte("Weatherwax"),
+			"dir/":    nil,
+		}))
+
+		require.Nil(t, cookies.CopyFileFS(fsys, src, dst, false))
+		cookiestest.RequireFileFS(t, fsys, dst, "Weatherwax")
+
+		require.NotNil(t, cookies.CopyFileFS(fsys, src, dst, false))
+		require.NotNil(t, cookies.CopyFileFS(fsys, src, src, true))
+		require.NotNil(t, cookies.CopyFileFS(fsys, filepath.Join(root, "dir"), dst, true))
+		require.Nil(t, cookies.CopyFileFS(fsys, src, dst, true))
+	})
+}
 
-	exp := []byte("\"What you see is all there is.\"")
-	act := []byte(a)
-	require.Equal(t, exp, act)
+func TestFileToQuote(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		f := filepath.Join(root, "abc.txt")
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"abc.txt": []byte("What you see is all there is."),
+		}))
+
+		a, e := cookies.FileToQuoteFS(fsys, f)
+		require.Nil(t, e)
+
+		exp := []byte("\"What you see is all there is.\"")
+		act := []byte(a)
+		require.Equal(t, exp, act)
+	})
 }
 
 func TestCreateFiles(t *testing.T) {
-	home, temp := startFileTest()
-	defer endFileTest(home, temp)
-
-	e := CreateFiles(temp, os.ModePerm, map[string][]byte{
-		"abc.txt":        []byte("Weatherwax"),
-		"xyz.txt":        []byte("Ogg"),
-		"nested/abc.txt": []byte("Garlick"),
-		"empty/":         nil,
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		e := cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"abc.txt":        []byte("Weatherwax"),
+			"xyz.txt":        []byte("Ogg"),
+			"nested/abc.txt": []byte("Garlick"),
+			"empty/":         nil,
+		})
+		require.Nil(t, e)
+
+		cookiestest.RequireFileFS(t, fsys, root+"/abc.txt", "Weatherwax")
+		cookiestest.RequireFileFS(t, fsys, root+"/xyz.txt", "Ogg")
+		cookiestest.RequireFileFS(t, fsys, root+"/nested/abc.txt", "Garlick")
+
+		ok, e := cookies.IsDirFS(fsys, root+"/empty")
+		require.Nil(t, e)
+		require.True(t, ok)
 	})
-	require.Nil(t, e)
+}
+
+func TestCreateFiles_Escapes(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		dir := filepath.Join(root, "dir")
+		require.Nil(t, fsys.MkdirAll(dir, os.ModePerm))
+		require.Nil(t, fsys.Symlink("..", filepath.Join(dir, "up")))
+
+		for _, p := range []string{"../evil.txt", "a/../../evil.txt", "/evil.txt", "up/evil.txt"} {
+			e := cookies.CreateFilesFS(fsys, dir, os.ModePerm, map[string][]byte{
+				p: []byte("Weatherwax"),
+			})
+			require.True(t, errors.Is(e, cookies.ErrPathEscapes), "%s: %+v", p, e)
+		}
 
-	requireFile(t, temp+"/abc.txt", "Weatherwax")
-	requireFile(t, temp+"/xyz.txt", "Ogg")
-	require.DirExists(t, temp+"/nested")
-	requireFile(t, temp+"/nested/abc.txt", "Garlick")
-	require.DirExists(t, temp+"/empty")
+		ok, e := cookies.FileExistsFS(fsys, filepath.Join(root, "evil.txt"))
+		require.Nil(t, e)
+		require.False(t, ok)
+	})
 }
@@ -0,0 +1,192 @@
+package cookies
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// FS is a file system used by the file helpers with an 'FS' suffix, e.g.
+// CopyFileFS. The helpers without the suffix use OSFS. MemFS provides an in
+// memory implementation for hermetic tests.
+type FS interface {
+	Open(name string) (File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.FileInfo, error) // Sorted by name
+	Mkdir(name string, perm os.FileMode) error
+	MkdirAll(name string, perm os.FileMode) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	Rename(oldname, newname string) error
+	Chmod(name string, mode os.FileMode) error
+	Chtimes(name string, atime, mtime time.Time) error
+	Symlink(oldname, newname string) error
+	Readlink(name string) (string, error)
+}
+
+// File is an open file within an FS.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.Closer
+	Name() string
+	Stat() (os.FileInfo, error)
+	Sync() error
+	Chmod(mode os.FileMode) error
+}
+
+// OSFS is the FS of the host operating system.
+var OSFS FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (File, error) {
+	return openOSFile(os.Open(name))
+}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return openOSFile(os.OpenFile(name, flag, perm))
+}
+
+// openOSFile avoids returning a non-nil File holding a nil *os.File.
+func openOSFile(f *os.File, e error) (File, error) {
+	if e != nil {
+		return nil, e
+	}
+	return f, nil
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFS) ReadDir(name string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(name)
+}
+
+func (osFS) Mkdir(name string, perm os.FileMode) error {
+	return os.Mkdir(name, perm)
+}
+
+func (osFS) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+func (osFS) Rename(oldname, newname string) error {
+	return os.Rename(oldname, newname)
+}
+
+func (osFS) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+func (osFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+func (osFS) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+func (osFS) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// ReadFileFS is ioutil.ReadFile for any FS.
+func ReadFileFS(fsys FS, f string) ([]byte, error) {
+	file, e := fsys.Open(f)
+	if e != nil {
+		return nil, e
+	}
+	defer file.Close()
+	return ioutil.ReadAll(file)
+}
+
+// WriteFileFS is ioutil.WriteFile for any FS.
+func WriteFileFS(fsys FS, f string, data []byte, perm os.FileMode) error {
+	file, e := fsys.OpenFile(f, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if e != nil {
+		return e
+	}
+
+	if _, e = file.Write(data); e != nil {
+		file.Close()
+		return e
+	}
+
+	return file.Close()
+}
+
+// evalSymlinksFS is filepath.EvalSymlinks for any FS. Paths of an FS other
+// than OSFS are resolved using '/' as the separator.
+func evalSymlinksFS(fsys FS, p string) (string, error) {
+	if fsys == OSFS {
+		return filepath.EvalSymlinks(p)
+	}
+
+	p = filepath.ToSlash(p)
+	resolved := "."
+	if path.IsAbs(p) {
+		resolved = "/"
+	}
+
+	pending := splitSafePath(p)
+	links := 0
+
+	for len(pending) > 0 {
+		elem := pending[0]
+		pending = pending[1:]
+
+		if elem == ".." {
+			resolved = path.Dir(resolved)
+			continue
+		}
+
+		cur := path.Join(resolved, elem)
+		info, e := fsys.Lstat(cur)
+		if e != nil {
+			return "", e
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = cur
+			continue
+		}
+
+		if links++; links > maxSafeLinks {
+			return "", &os.PathError{Op: "evalsymlinks", Path: p, Err: errors.New("Too many symbolic links")}
+		}
+
+		target, e := fsys.Readlink(cur)
+		if e != nil {
+			return "", e
+		}
+
+		target = filepath.ToSlash(target)
+		if path.IsAbs(target) {
+			resolved = "/"
+		}
+		pending = append(splitSafePath(target), pending...)
+	}
+
+	return resolved, nil
+}
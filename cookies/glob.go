@@ -0,0 +1,109 @@
+package cookies
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether the '/' separated 'name' matches the shell
+// pattern 'pattern'. Each path segment is matched using path.Match while a
+// segment of '**' matches zero or more whole segments. Alternatives may be
+// given within braces, e.g. '**/*.{go,mod}'. The only possible error is
+// path.ErrBadPattern.
+func MatchGlob(pattern, name string) (bool, error) {
+	for _, p := range expandBraces(pattern) {
+		ok, e := matchGlobSegments(strings.Split(p, "/"), strings.Split(name, "/"))
+		if e != nil || ok {
+			return ok, e
+		}
+	}
+	return false, nil
+}
+
+// checkGlob returns path.ErrBadPattern if 'pattern' is malformed.
+func checkGlob(pattern string) error {
+	for _, p := range expandBraces(pattern) {
+		for _, seg := range strings.Split(p, "/") {
+			if _, e := path.Match(seg, ""); e != nil {
+				return Wrap(e, "Bad pattern %q", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+func matchGlobSegments(patterns, names []string) (bool, error) {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for len(patterns) > 0 && patterns[0] == "**" {
+				patterns = patterns[1:]
+			}
+			if len(patterns) == 0 {
+				return true, nil
+			}
+			for i := 0; i <= len(names); i++ {
+				ok, e := matchGlobSegments(patterns, names[i:])
+				if e != nil || ok {
+					return ok, e
+				}
+			}
+			return false, nil
+		}
+
+		if len(names) == 0 {
+			return false, nil
+		}
+
+		ok, e := path.Match(patterns[0], names[0])
+		if e != nil || !ok {
+			return false, e
+		}
+
+		patterns, names = patterns[1:], names[1:]
+	}
+
+	return len(names) == 0, nil
+}
+
+// expandBraces expands the first, outermost, brace group within 'pattern'
+// recursively returning every alternative. Unbalanced braces are left as is.
+func expandBraces(pattern string) []string {
+
+	start, depth := -1, 0
+	var alts []string
+	last := 0
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			if depth == 0 {
+				start, last = i, i+1
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				alts = append(alts, pattern[last:i])
+				last = i + 1
+			}
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			if depth--; depth > 0 {
+				continue
+			}
+			alts = append(alts, pattern[last:i])
+			pre, post := pattern[:start], pattern[i+1:]
+
+			var r []string
+			for _, alt := range alts {
+				r = append(r, expandBraces(pre+alt+post)...)
+			}
+			return r
+		}
+	}
+
+	return []string{pattern}
+}
@@ -0,0 +1,345 @@
+package cookies
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HashAlgo is a hashing algorithm used to hash files.
+type HashAlgo int
+
+const (
+	SHA256 HashAlgo = iota
+	SHA1
+	CRC32
+)
+
+// String returns the name of the algorithm.
+func (a HashAlgo) String() string {
+	switch a {
+	case SHA256:
+		return "sha256"
+	case SHA1:
+		return "sha1"
+	case CRC32:
+		return "crc32"
+	default:
+		return "unknown"
+	}
+}
+
+// New returns a new hash.Hash for the algorithm.
+func (a HashAlgo) New() hash.Hash {
+	switch a {
+	case SHA1:
+		return sha1.New()
+	case CRC32:
+		return crc32.NewIEEE()
+	default:
+		return sha256.New()
+	}
+}
+
+// ParseHashAlgo returns the HashAlgo named 's' as returned by
+// HashAlgo.String.
+func ParseHashAlgo(s string) (HashAlgo, error) {
+	for _, a := range []HashAlgo{SHA256, SHA1, CRC32} {
+		if strings.EqualFold(s, a.String()) {
+			return a, nil
+		}
+	}
+	return SHA256, fmt.Errorf("Unknown hash algorithm: %s", s)
+}
+
+// HashFile returns the hex encoded hash of the content of file 'f'.
+func HashFile(f string, algo HashAlgo) (string, error) {
+	return HashFileFS(OSFS, f, algo)
+}
+
+// HashFileFS is HashFile for any FS.
+func HashFileFS(fsys FS, f string, algo HashAlgo) (string, error) {
+	file, e := fsys.Open(f)
+	if e != nil {
+		return "", e
+	}
+	defer file.Close()
+
+	h := algo.New()
+	if _, e := io.Copy(h, file); e != nil {
+		return "", e
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashDir returns the hex encoded hash of the regular files, selected by
+// 'opts', within the directory tree 'root'. The hash covers the path, mode,
+// and content of each file so renaming, chmodding, or editing any of them
+// produces a different hash.
+func HashDir(root string, algo HashAlgo, opts WalkOptions) (string, error) {
+	return HashDirFS(OSFS, root, algo, opts)
+}
+
+// HashDirFS is HashDir for any FS.
+func HashDirFS(fsys FS, root string, algo HashAlgo, opts WalkOptions) (string, error) {
+	m, e := BuildManifestFS(fsys, root, algo, opts)
+	if e != nil {
+		return "", e
+	}
+
+	h := algo.New()
+	h.Write(m.Format())
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ManifestEntry describes a single file within a Manifest.
+type ManifestEntry struct {
+	Path string // Relative to the manifest root separated by '/'
+	Hash string
+	Size int64
+	Mode os.FileMode // Permission bits only
+}
+
+// Manifest records the hash, size, and mode of every file within a directory
+// tree so the tree may be verified later.
+type Manifest struct {
+	Algo    HashAlgo
+	Entries []ManifestEntry // Sorted by path
+}
+
+// BuildManifest creates a Manifest of the regular files, selected by 'opts',
+// within the directory tree 'root'.
+func BuildManifest(root string, algo HashAlgo, opts WalkOptions) (Manifest, error) {
+	return BuildManifestFS(OSFS, root, algo, opts)
+}
+
+// BuildManifestFS is BuildManifest for any FS.
+func BuildManifestFS(fsys FS, root string, algo HashAlgo, opts WalkOptions) (Manifest, error) {
+	m := Manifest{Algo: algo}
+
+	e := WalkFS(fsys, root, opts, func(entry WalkEntry) error {
+		if !entry.Info.Mode().IsRegular() {
+			return nil
+		}
+
+		h, e := HashFileFS(fsys, entry.Path, algo)
+		if e != nil {
+			return e
+		}
+
+		m.Entries = append(m.Entries, ManifestEntry{
+			Path: entry.Rel,
+			Hash: h,
+			Size: entry.Info.Size(),
+			Mode: entry.Info.Mode().Perm(),
+		})
+		return nil
+	})
+
+	if e != nil {
+		return Manifest{}, e
+	}
+
+	sort.Slice(m.Entries, func(i, j int) bool {
+		return m.Entries[i].Path < m.Entries[j].Path
+	})
+	return m, nil
+}
+
+const manifestHeader = "# manifest "
+
+// Format returns the manifest in its textual form. The first line names the
+// algorithm then each following line holds the hash, size, octal mode, and
+// path of a file separated by single spaces:
+//
+//	# manifest sha256
+//	9f86d081884c7d65... 4 0644 abc.txt
+func (m Manifest) Format() []byte {
+	buf := bytes.Buffer{}
+	buf.WriteString(manifestHeader + m.Algo.String() + "\n")
+	for _, en := range m.Entries {
+		fmt.Fprintf(&buf, "%s %d %04o %s\n", en.Hash, en.Size, en.Mode, en.Path)
+	}
+	return buf.Bytes()
+}
+
+// ParseManifest parses a manifest in the form returned by Manifest.Format.
+func ParseManifest(data []byte) (Manifest, error) {
+	m := Manifest{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+
+	if !sc.Scan() || !strings.HasPrefix(sc.Text(), manifestHeader) {
+		return m, fmt.Errorf("Missing manifest header")
+	}
+
+	var e error
+	if m.Algo, e = ParseHashAlgo(strings.TrimPrefix(sc.Text(), manifestHeader)); e != nil {
+		return m, e
+	}
+
+	for n := 2; sc.Scan(); n++ {
+		en, e := parseManifestEntry(sc.Text())
+		if e != nil {
+			return m, Wrap(e, "Bad manifest entry on line %d", n)
+		}
+		m.Entries = append(m.Entries, en)
+	}
+
+	return m, sc.Err()
+}
+
+func parseManifestEntry(line string) (ManifestEntry, error) {
+	fields := strings.SplitN(line, " ", 4)
+	if len(fields) != 4 {
+		return ManifestEntry{}, fmt.Errorf("Expected 4 fields: %q", line)
+	}
+
+	size, e := strconv.ParseInt(fields[1], 10, 64)
+	if e != nil {
+		return ManifestEntry{}, e
+	}
+
+	mode, e := strconv.ParseUint(fields[2], 8, 32)
+	if e != nil {
+		return ManifestEntry{}, e
+	}
+
+	return ManifestEntry{
+		Path: fields[3],
+		Hash: fields[0],
+		Size: size,
+		Mode: os.FileMode(mode),
+	}, nil
+}
+
+// ReadManifest reads and parses the manifest file 'f'.
+func ReadManifest(f string) (Manifest, error) {
+	data, e := ioutil.ReadFile(f)
+	if e != nil {
+		return Manifest{}, e
+	}
+	return ParseManifest(data)
+}
+
+// WriteManifest writes the manifest 'm' to the file 'f' using
+// WriteFileAtomic.
+func WriteManifest(f string, m Manifest) error {
+	return WriteFileAtomic(f, m.Format(), 0666)
+}
+
+// MismatchKind is the way in which a file differs from its manifest entry.
+type MismatchKind int
+
+const (
+	MismatchMissing    MismatchKind = iota + 1 // In the manifest but not the tree
+	MismatchUnexpected                         // In the tree but not the manifest
+	MismatchHash
+	MismatchSize
+	MismatchMode
+)
+
+// String returns a short description of the kind.
+func (k MismatchKind) String() string {
+	switch k {
+	case MismatchMissing:
+		return "missing"
+	case MismatchUnexpected:
+		return "unexpected"
+	case MismatchHash:
+		return "hash"
+	case MismatchSize:
+		return "size"
+	case MismatchMode:
+		return "mode"
+	default:
+		return "unknown"
+	}
+}
+
+// ManifestMismatch is a single difference between a manifest and a tree.
+type ManifestMismatch struct {
+	Path     string
+	Kind     MismatchKind
+	Expected string // Value within the manifest, empty if not applicable
+	Actual   string // Value within the tree, empty if not applicable
+}
+
+// String returns the mismatch as a single human readable line.
+func (mm ManifestMismatch) String() string {
+	if mm.Expected == "" && mm.Actual == "" {
+		return fmt.Sprintf("%s: %s", mm.Path, mm.Kind)
+	}
+	return fmt.Sprintf("%s: %s differs, expected %s, found %s",
+		mm.Path, mm.Kind, mm.Expected, mm.Actual)
+}
+
+// VerifyManifest compares the directory tree 'root' against the manifest 'm'
+// returning every mismatch found, sorted by path. 'opts' should select the
+// same files as when the manifest was built.
+func VerifyManifest(root string, m Manifest, opts WalkOptions) ([]ManifestMismatch, error) {
+	return VerifyManifestFS(OSFS, root, m, opts)
+}
+
+// VerifyManifestFS is VerifyManifest for any FS.
+func VerifyManifestFS(fsys FS, root string, m Manifest, opts WalkOptions) ([]ManifestMismatch, error) {
+
+	act, e := BuildManifestFS(fsys, root, m.Algo, opts)
+	if e != nil {
+		return nil, e
+	}
+
+	found := make(map[string]ManifestEntry, len(act.Entries))
+	for _, en := range act.Entries {
+		found[en.Path] = en
+	}
+
+	var r []ManifestMismatch
+	expected := make(map[string]bool, len(m.Entries))
+
+	for _, exp := range m.Entries {
+		expected[exp.Path] = true
+		r = append(r, compareManifestEntry(exp, found)...)
+	}
+
+	for _, en := range act.Entries {
+		if !expected[en.Path] {
+			r = append(r, ManifestMismatch{Path: en.Path, Kind: MismatchUnexpected})
+		}
+	}
+
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].Path < r[j].Path
+	})
+	return r, nil
+}
+
+func compareManifestEntry(exp ManifestEntry, found map[string]ManifestEntry) []ManifestMismatch {
+
+	act, ok := found[exp.Path]
+	if !ok {
+		return []ManifestMismatch{{Path: exp.Path, Kind: MismatchMissing}}
+	}
+
+	var r []ManifestMismatch
+	check := func(k MismatchKind, e, a string) {
+		if e != a {
+			r = append(r, ManifestMismatch{Path: exp.Path, Kind: k, Expected: e, Actual: a})
+		}
+	}
+
+	check(MismatchHash, exp.Hash, act.Hash)
+	check(MismatchSize, strconv.FormatInt(exp.Size, 10), strconv.FormatInt(act.Size, 10))
+	check(MismatchMode, fmt.Sprintf("%04o", exp.Mode), fmt.Sprintf("%04o", act.Mode))
+	return r
+}
@@ -0,0 +1,134 @@
+package cookies_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashFile(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "abc.txt")
+	require.Nil(t, ioutil.WriteFile(f, []byte("test"), 0666))
+
+	requireHash := func(algo cookies.HashAlgo, exp string) {
+		act, e := cookies.HashFile(f, algo)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, exp, act)
+	}
+
+	requireHash(cookies.SHA256, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+	requireHash(cookies.SHA1, "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3")
+	requireHash(cookies.CRC32, "d87f7e0c")
+}
+
+func TestHashDir(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, 0755, map[string][]byte{
+		"abc.txt":        []byte("Weatherwax"),
+		"nested/xyz.txt": []byte("Ogg"),
+	}))
+
+	a, e := cookies.HashDir(temp, cookies.SHA256, cookies.WalkOptions{})
+	require.Nil(t, e, "%+v", e)
+
+	b, e := cookies.HashDir(temp, cookies.SHA256, cookies.WalkOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, a, b)
+
+	require.Nil(t, os.Rename(temp+"/abc.txt", temp+"/abc.md"))
+	b, e = cookies.HashDir(temp, cookies.SHA256, cookies.WalkOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.NotEqual(t, a, b)
+}
+
+func TestManifest(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	root := filepath.Join(temp, "root")
+	require.Nil(t, cookies.CreateFiles(root, 0755, map[string][]byte{
+		"abc.txt":        []byte("Weatherwax"),
+		"xyz.txt":        []byte("Ogg"),
+		"nested/abc.txt": []byte("Garlick"),
+	}))
+
+	m, e := cookies.BuildManifest(root, cookies.SHA1, cookies.WalkOptions{})
+	require.Nil(t, e, "%+v", e)
+
+	f := filepath.Join(temp, "manifest.txt")
+	require.Nil(t, cookies.WriteManifest(f, m))
+
+	act, e := cookies.ReadManifest(f)
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, m, act)
+
+	r, e := cookies.VerifyManifest(root, act, cookies.WalkOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Empty(t, r)
+
+	require.Nil(t, ioutil.WriteFile(root+"/abc.txt", []byte("Esme"), 0755))
+	require.Nil(t, os.Chmod(root+"/abc.txt", 0600))
+	require.Nil(t, os.Remove(root+"/xyz.txt"))
+	require.Nil(t, ioutil.WriteFile(root+"/new.txt", []byte("Nanny"), 0755))
+
+	r, e = cookies.VerifyManifest(root, act, cookies.WalkOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []cookies.ManifestMismatch{
+		{Path: "abc.txt", Kind: cookies.MismatchHash, Expected: m.Entries[0].Hash, Actual: r[0].Actual},
+		{Path: "abc.txt", Kind: cookies.MismatchSize, Expected: "10", Actual: "4"},
+		{Path: "abc.txt", Kind: cookies.MismatchMode, Expected: "0755", Actual: "0600"},
+		{Path: "new.txt", Kind: cookies.MismatchUnexpected},
+		{Path: "xyz.txt", Kind: cookies.MismatchMissing},
+	}, r)
+}
+
+func TestParseManifest_Bad(t *testing.T) {
+	_, e := cookies.ParseManifest([]byte("abc 1 0644 abc.txt\n"))
+	require.NotNil(t, e)
+
+	_, e = cookies.ParseManifest([]byte("# manifest md5\n"))
+	require.NotNil(t, e)
+
+	_, e = cookies.ParseManifest([]byte("# manifest sha1\nabc x 0644 abc.txt\n"))
+	require.NotNil(t, e)
+}
+
+func TestHashDirFS_AND_ManifestFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, 0755, map[string][]byte{
+			"abc.txt":        []byte("test"),
+			"nested/xyz.txt": []byte("Ogg"),
+		}))
+
+		act, e := cookies.HashFileFS(fsys, root+"/abc.txt", cookies.SHA256)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", act)
+
+		a, e := cookies.HashDirFS(fsys, root, cookies.SHA256, cookies.WalkOptions{})
+		require.Nil(t, e, "%+v", e)
+
+		m, e := cookies.BuildManifestFS(fsys, root, cookies.SHA256, cookies.WalkOptions{})
+		require.Nil(t, e, "%+v", e)
+
+		require.Nil(t, cookies.WriteFileFS(fsys, root+"/abc.txt", []byte("Weatherwax"), 0666))
+
+		b, e := cookies.HashDirFS(fsys, root, cookies.SHA256, cookies.WalkOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.NotEqual(t, a, b)
+
+		r, e := cookies.VerifyManifestFS(fsys, root, m, cookies.WalkOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []cookies.ManifestMismatch{
+			{Path: "abc.txt", Kind: cookies.MismatchHash, Expected: m.Entries[0].Hash, Actual: r[0].Actual},
+			{Path: "abc.txt", Kind: cookies.MismatchSize, Expected: "4", Actual: "10"},
+		}, r)
+	})
+}
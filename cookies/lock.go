@@ -0,0 +1,205 @@
+package cookies
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLocked is the cause of the error returned by FileLock.LockTimeout when
+// the lock could not be acquired in time.
+var ErrLocked = errors.New("File is locked")
+
+// lockPollInterval is how often a lock is retried while waiting for it.
+const lockPollInterval = 50 * time.Millisecond
+
+// LockMode specifies how a FileLock is implemented.
+type LockMode int
+
+const (
+	// LockFlock uses an advisory flock on the lock file. The lock is released
+	// by the operating system if the holding process dies. Only supported on
+	// Unix like systems.
+	LockFlock LockMode = iota
+
+	// LockPID creates the lock file exclusively and writes the holding
+	// process ID into it. The file is removed on unlock. A lock file left
+	// behind by a process that no longer exists is considered stale and is
+	// replaced. Works on any system and file system but is best effort, two
+	// processes replacing the same stale lock at once may both succeed.
+	LockPID
+)
+
+// FileLock is an advisory lock, backed by a file, used to coordinate
+// processes. It is not goroutine safe and is not reentrant; locking twice
+// from the same process, even via different FileLocks, blocks.
+type FileLock struct {
+	path string
+	mode LockMode
+	f    *os.File // Open while a LockFlock is held
+	held bool
+}
+
+// NewFileLock returns a new unlocked FileLock using the file 'path'. The file
+// is created on locking if it doesn't exist but its directory must.
+func NewFileLock(path string, mode LockMode) *FileLock {
+	return &FileLock{path: path, mode: mode}
+}
+
+// Path returns the path of the lock file.
+func (l *FileLock) Path() string {
+	return l.path
+}
+
+// Lock acquires the lock waiting for as long as it takes.
+func (l *FileLock) Lock() error {
+	if l.held {
+		return fmt.Errorf("Lock already held: %s", l.path)
+	}
+
+	if l.mode == LockFlock {
+		return l.flock(true)
+	}
+
+	for {
+		ok, e := l.TryLock()
+		if e != nil || ok {
+			return e
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// TryLock attempts to acquire the lock without waiting returning false if
+// another process, or FileLock, holds it.
+func (l *FileLock) TryLock() (bool, error) {
+	if l.held {
+		return false, fmt.Errorf("Lock already held: %s", l.path)
+	}
+
+	if l.mode == LockFlock {
+		e := l.flock(false)
+		if e == ErrLocked {
+			return false, nil
+		}
+		return e == nil, e
+	}
+
+	return l.tryPIDLock()
+}
+
+// LockTimeout acquires the lock waiting up to 'timeout' for it. An error
+// wrapping ErrLocked is returned if the timeout expires first.
+func (l *FileLock) LockTimeout(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		ok, e := l.TryLock()
+		if e != nil || ok {
+			return e
+		}
+
+		if time.Now().After(deadline) {
+			return Wrap(ErrLocked, "Timed out after %s waiting for %s", timeout, l.path)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Unlock releases the lock.
+func (l *FileLock) Unlock() error {
+	if !l.held {
+		return fmt.Errorf("Lock not held: %s", l.path)
+	}
+	l.held = false
+
+	if l.mode == LockFlock {
+		f := l.f
+		l.f = nil
+		if e := funlock(f); e != nil {
+			f.Close()
+			return e
+		}
+		return f.Close()
+	}
+
+	pid, e := readLockPID(l.path)
+	if e != nil {
+		return e
+	}
+	if pid != os.Getpid() {
+		return fmt.Errorf("Lock file %s taken by process %d", l.path, pid)
+	}
+	return os.Remove(l.path)
+}
+
+// flock opens the lock file and locks it. ErrLocked is returned if 'block' is
+// false and the lock is held elsewhere.
+func (l *FileLock) flock(block bool) error {
+	f, e := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0666)
+	if e != nil {
+		return e
+	}
+
+	if e := flock(f, block); e != nil {
+		f.Close()
+		return e
+	}
+
+	l.f, l.held = f, true
+	return nil
+}
+
+func (l *FileLock) tryPIDLock() (bool, error) {
+	for retry := true; ; retry = false {
+		f, e := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if e == nil {
+			_, e = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			if e2 := f.Close(); e == nil {
+				e = e2
+			}
+			if e != nil {
+				os.Remove(l.path)
+				return false, e
+			}
+			l.held = true
+			return true, nil
+		}
+
+		if !os.IsExist(e) {
+			return false, e
+		}
+
+		// An unreadable PID is treated as a lock that is still being
+		// written by its owner.
+		pid, e := readLockPID(l.path)
+		if os.IsNotExist(e) && retry {
+			continue
+		}
+		if e != nil || !retry || processAlive(pid) {
+			return false, nil
+		}
+
+		if e := os.Remove(l.path); e != nil && !os.IsNotExist(e) {
+			return false, Wrap(e, "Failed to remove stale lock %s", l.path)
+		}
+	}
+}
+
+// readLockPID returns the process ID within the lock file 'f'.
+func readLockPID(f string) (int, error) {
+	data, e := ioutil.ReadFile(f)
+	if e != nil {
+		return 0, e
+	}
+
+	pid, e := strconv.Atoi(strings.TrimSpace(string(data)))
+	if e != nil || pid <= 0 {
+		return 0, fmt.Errorf("Bad process ID in lock file %s: %q", f, data)
+	}
+	return pid, nil
+}
@@ -0,0 +1,33 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package cookies
+
+import (
+	"errors"
+	"os"
+)
+
+// DefaultLockMode is the most robust LockMode supported by the system.
+const DefaultLockMode = LockPID
+
+var errFlockUnsupported = errors.New("flock is not supported on this system, use LockPID")
+
+func flock(f *os.File, block bool) error {
+	return &os.PathError{Op: "flock", Path: f.Name(), Err: errFlockUnsupported}
+}
+
+func funlock(f *os.File) error {
+	return &os.PathError{Op: "funlock", Path: f.Name(), Err: errFlockUnsupported}
+}
+
+// processAlive returns true if the process 'pid' exists. Where that can't be
+// determined the process is assumed to exist so locks are never stolen.
+func processAlive(pid int) bool {
+	p, e := os.FindProcess(pid)
+	if e != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,101 @@
+package cookies_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func requireLocking(t *testing.T, f string, mode cookies.LockMode) {
+	a, b := cookies.NewFileLock(f, mode), cookies.NewFileLock(f, mode)
+
+	ok, e := a.TryLock()
+	require.Nil(t, e, "%+v", e)
+	require.True(t, ok)
+	require.NotNil(t, a.Lock())
+
+	ok, e = b.TryLock()
+	require.Nil(t, e, "%+v", e)
+	require.False(t, ok)
+
+	e = b.LockTimeout(time.Millisecond)
+	require.True(t, errors.Is(e, cookies.ErrLocked), "%+v", e)
+
+	started, done := make(chan struct{}), make(chan error)
+	go func() {
+		close(started)
+		done <- b.Lock()
+	}()
+
+	<-started
+	select {
+	case e := <-done:
+		require.Fail(t, "Lock acquired while held", "%+v", e)
+	default:
+	}
+	require.Nil(t, a.Unlock())
+	require.Nil(t, <-done)
+	require.NotNil(t, a.Unlock())
+
+	ok, e = a.TryLock()
+	require.Nil(t, e, "%+v", e)
+	require.False(t, ok)
+
+	require.Nil(t, b.Unlock())
+	require.Nil(t, a.LockTimeout(time.Second))
+	require.Nil(t, a.Unlock())
+}
+
+func TestFileLock_Flock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("flock not supported")
+	}
+
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "build.lock")
+	requireLocking(t, f, cookies.LockFlock)
+	require.FileExists(t, f)
+}
+
+func TestFileLock_PID(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "build.lock")
+	requireLocking(t, f, cookies.LockPID)
+	cookiestest.RequireNotExists(t, f)
+
+	l := cookies.NewFileLock(f, cookies.LockPID)
+	require.Nil(t, l.Lock())
+	cookiestest.RequireFile(t, f, strconv.Itoa(os.Getpid())+"\n")
+	require.Nil(t, l.Unlock())
+}
+
+func TestFileLock_PIDStale(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "build.lock")
+	l := cookies.NewFileLock(f, cookies.LockPID)
+
+	// Well beyond the default maximum process ID of any supported system.
+	require.Nil(t, ioutil.WriteFile(f, []byte("2147483000\n"), 0666))
+	ok, e := l.TryLock()
+	require.Nil(t, e, "%+v", e)
+	require.True(t, ok)
+	cookiestest.RequireFile(t, f, strconv.Itoa(os.Getpid())+"\n")
+	require.Nil(t, l.Unlock())
+
+	require.Nil(t, ioutil.WriteFile(f, []byte("garbage"), 0666))
+	ok, e = l.TryLock()
+	require.Nil(t, e, "%+v", e)
+	require.False(t, ok)
+}
@@ -0,0 +1,49 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package cookies
+
+import (
+	"os"
+	"syscall"
+)
+
+// DefaultLockMode is the most robust LockMode supported by the system.
+const DefaultLockMode = LockFlock
+
+// flock places an exclusive flock on the file 'f'. ErrLocked is returned if
+// 'block' is false and another file description holds the lock.
+func flock(f *os.File, block bool) error {
+	how := syscall.LOCK_EX
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		e := syscall.Flock(int(f.Fd()), how)
+		switch e {
+		case nil:
+			return nil
+		case syscall.EINTR:
+			continue
+		case syscall.EWOULDBLOCK:
+			return ErrLocked
+		default:
+			return &os.PathError{Op: "flock", Path: f.Name(), Err: e}
+		}
+	}
+}
+
+func funlock(f *os.File) error {
+	if e := syscall.Flock(int(f.Fd()), syscall.LOCK_UN); e != nil {
+		return &os.PathError{Op: "funlock", Path: f.Name(), Err: e}
+	}
+	return nil
+}
+
+// processAlive returns true if the process 'pid' exists. A process owned by
+// another user still counts.
+func processAlive(pid int) bool {
+	e := syscall.Kill(pid, 0)
+	return e == nil || e == syscall.EPERM
+}
@@ -0,0 +1,676 @@
+package cookies
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFS is an in memory FS. Paths may use either separator and relative paths
+// are resolved against the root, '/', since a MemFS has no working directory.
+// Modes, modification times, and symbolic links are recorded as on a real
+// file system but permissions are not enforced and no umask is applied.
+//
+// Any operation may be made to fail using MemFS.Fail. A MemFS is safe for use
+// by multiple goroutines.
+type MemFS struct {
+	mu     sync.Mutex
+	root   *memNode
+	faults map[memFault]error
+}
+
+// Errors wrapped within the *os.PathErrors returned by a MemFS. They match
+// the messages of their syscall counterparts which aren't defined on every
+// platform.
+var (
+	errMemNotDir   = errors.New("not a directory")
+	errMemIsDir    = errors.New("is a directory")
+	errMemLoop     = errors.New("too many levels of symbolic links")
+	errMemNotEmpty = errors.New("directory not empty")
+	errMemBadFile  = errors.New("bad file descriptor")
+)
+
+type memNode struct {
+	mode     os.FileMode // Type and permission bits
+	modTime  time.Time
+	data     []byte              // Regular files only
+	target   string              // Symbolic links only
+	children map[string]*memNode // Directories only
+}
+
+type memFault struct {
+	op   string
+	name string
+}
+
+// maxMemLinks is the number of symbolic links followed before giving up.
+const maxMemLinks = 40
+
+// NewMemFS returns a new MemFS containing only the root directory.
+func NewMemFS() *MemFS {
+	return &MemFS{
+		root:   newMemDir(os.ModePerm),
+		faults: map[memFault]error{},
+	}
+}
+
+func newMemDir(perm os.FileMode) *memNode {
+	return &memNode{
+		mode:     os.ModeDir | perm.Perm(),
+		modTime:  time.Now(),
+		children: map[string]*memNode{},
+	}
+}
+
+// Fail makes the operation 'op' on the file 'name' fail with the error 'e'.
+// Operations are named after the FS and File methods in lower case, e.g.
+// "open", "rename", "read", "write", "sync", "close". Opening a file with
+// OpenFile counts as "open". An empty 'name' applies to all files. Passing a
+// nil error removes the failure.
+func (m *MemFS) Fail(op, name string, e error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if name != "" {
+		name = cleanMemPath(name)
+	}
+
+	f := memFault{op: strings.ToLower(op), name: name}
+	if e == nil {
+		delete(m.faults, f)
+		return
+	}
+	m.faults[f] = e
+}
+
+// fault returns the injected error for 'op' on 'name', if any, as an
+// *os.PathError.
+func (m *MemFS) fault(op, name string) error {
+	e, ok := m.faults[memFault{op, cleanMemPath(name)}]
+	if !ok {
+		e, ok = m.faults[memFault{op, ""}]
+	}
+	if !ok {
+		return nil
+	}
+	return &os.PathError{Op: op, Path: name, Err: e}
+}
+
+func cleanMemPath(name string) string {
+	return path.Clean("/" + filepath.ToSlash(name))
+}
+
+// memPathElems returns the elements of 'name' without resolving any '.' or
+// '..' since they can only be resolved once preceding links are followed.
+func memPathElems(name string) []string {
+	var elems []string
+	for _, el := range strings.Split(filepath.ToSlash(name), "/") {
+		if el != "" {
+			elems = append(elems, el)
+		}
+	}
+	return elems
+}
+
+func memErr(op, name string, e error) error {
+	return &os.PathError{Op: op, Path: name, Err: e}
+}
+
+// lookup resolves 'name' returning the directory that holds it, its base name,
+// and its node. The node is nil if the directory exists but not the file. If
+// 'follow' is true then a symbolic link at the end of the path is followed;
+// links within the path are always followed. As on a real file system, the
+// path is resolved an element at a time so a '..' after a link leads to the
+// parent of the link's target. The root directory is returned with an empty
+// base name and nil parent.
+func (m *MemFS) lookup(op, name string, follow bool) (parent *memNode, base string, n *memNode, e error) {
+
+	type step struct {
+		node *memNode
+		name string
+	}
+
+	dirs := []step{{m.root, ""}} // From the root to the current directory
+	elems := memPathElems(name)
+	links := 0
+
+	for len(elems) > 0 {
+		cur := dirs[len(dirs)-1].node
+		if !cur.mode.IsDir() {
+			return nil, "", nil, memErr(op, name, errMemNotDir)
+		}
+
+		el, last := elems[0], len(elems) == 1
+		elems = elems[1:]
+
+		switch el {
+		case ".":
+			continue
+		case "..":
+			if len(dirs) > 1 {
+				dirs = dirs[:len(dirs)-1]
+			}
+			continue
+		}
+
+		child, ok := cur.children[el]
+		if !ok {
+			if last {
+				return cur, el, nil, nil
+			}
+			return nil, "", nil, memErr(op, name, os.ErrNotExist)
+		}
+
+		if child.mode&os.ModeSymlink != 0 && (!last || follow) {
+			if links++; links > maxMemLinks {
+				return nil, "", nil, memErr(op, name, errMemLoop)
+			}
+			if path.IsAbs(child.target) {
+				dirs = dirs[:1]
+			}
+			elems = append(memPathElems(child.target), elems...)
+			continue
+		}
+
+		dirs = append(dirs, step{child, el})
+	}
+
+	if len(dirs) == 1 {
+		return nil, "", m.root, nil
+	}
+	top := dirs[len(dirs)-1]
+	return dirs[len(dirs)-2].node, top.name, top.node, nil
+}
+
+// existing is lookup but returns an error if the file doesn't exist.
+func (m *MemFS) existing(op, name string, follow bool) (parent *memNode, base string, n *memNode, e error) {
+	if e = m.fault(op, name); e != nil {
+		return nil, "", nil, e
+	}
+	parent, base, n, e = m.lookup(op, name, follow)
+	if e == nil && n == nil {
+		e = memErr(op, name, os.ErrNotExist)
+	}
+	return parent, base, n, e
+}
+
+// Open opens the file 'name' for reading.
+func (m *MemFS) Open(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile opens the file 'name' using the os.O_* flags 'flag' creating it
+// with 'perm' if os.O_CREATE is given and it doesn't exist.
+func (m *MemFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.fault("open", name); e != nil {
+		return nil, e
+	}
+
+	parent, base, n, e := m.lookup("open", name, true)
+	if e != nil {
+		return nil, e
+	}
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+
+	switch {
+	case n == nil && flag&os.O_CREATE == 0:
+		return nil, memErr("open", name, os.ErrNotExist)
+
+	case n == nil:
+		n = &memNode{mode: perm.Perm(), modTime: time.Now()}
+		parent.children[base] = n
+		parent.modTime = n.modTime
+
+	case flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, memErr("open", name, os.ErrExist)
+
+	case n.mode.IsDir() && writable:
+		return nil, memErr("open", name, errMemIsDir)
+
+	case flag&os.O_TRUNC != 0 && writable:
+		n.data, n.modTime = nil, time.Now()
+	}
+
+	return &memFile{fs: m, name: name, node: n, flag: flag}, nil
+}
+
+// Stat returns the file info of 'name' following symbolic links.
+func (m *MemFS) Stat(name string) (os.FileInfo, error) {
+	return m.stat("stat", name, true)
+}
+
+// Lstat returns the file info of 'name' without following a final symbolic
+// link.
+func (m *MemFS) Lstat(name string) (os.FileInfo, error) {
+	return m.stat("lstat", name, false)
+}
+
+func (m *MemFS) stat(op, name string, follow bool) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, n, e := m.existing(op, name, follow)
+	if e != nil {
+		return nil, e
+	}
+	return newMemInfo(path.Base(cleanMemPath(name)), n), nil
+}
+
+// ReadDir returns the file info of each file within the directory 'name'
+// sorted by name.
+func (m *MemFS) ReadDir(name string) ([]os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, n, e := m.existing("readdir", name, true)
+	if e != nil {
+		return nil, e
+	}
+	if !n.mode.IsDir() {
+		return nil, memErr("readdir", name, errMemNotDir)
+	}
+
+	infos := make([]os.FileInfo, 0, len(n.children))
+	for childName, child := range n.children {
+		infos = append(infos, newMemInfo(childName, child))
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	return infos, nil
+}
+
+// Mkdir creates the directory 'name'.
+func (m *MemFS) Mkdir(name string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.fault("mkdir", name); e != nil {
+		return e
+	}
+
+	parent, base, n, e := m.lookup("mkdir", name, false)
+	switch {
+	case e != nil:
+		return e
+	case n != nil:
+		return memErr("mkdir", name, os.ErrExist)
+	}
+
+	parent.children[base] = newMemDir(perm)
+	parent.modTime = time.Now()
+	return nil
+}
+
+// MkdirAll creates the directory 'name' along with any missing parents.
+func (m *MemFS) MkdirAll(name string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.fault("mkdirall", name); e != nil {
+		return e
+	}
+
+	p := ""
+	for _, el := range memPathElems(name) {
+		p += "/" + el
+
+		parent, base, n, e := m.lookup("mkdir", p, true)
+		switch {
+		case e != nil:
+			return e
+		case n == nil:
+			parent.children[base] = newMemDir(perm)
+			parent.modTime = time.Now()
+		case !n.mode.IsDir():
+			return memErr("mkdir", p, errMemNotDir)
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the file or empty directory 'name'.
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, base, n, e := m.existing("remove", name, false)
+	switch {
+	case e != nil:
+		return e
+	case parent == nil:
+		return memErr("remove", name, os.ErrInvalid)
+	case n.mode.IsDir() && len(n.children) > 0:
+		return memErr("remove", name, errMemNotEmpty)
+	}
+
+	delete(parent.children, base)
+	parent.modTime = time.Now()
+	return nil
+}
+
+// RemoveAll removes 'name' and everything it contains. No error is returned
+// if 'name' doesn't exist.
+func (m *MemFS) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.fault("removeall", name); e != nil {
+		return e
+	}
+
+	parent, base, n, e := m.lookup("removeall", name, false)
+	if os.IsNotExist(e) || (e == nil && n == nil) {
+		return nil
+	}
+	if e != nil {
+		return e
+	}
+
+	if parent == nil {
+		n.children = map[string]*memNode{}
+		return nil
+	}
+
+	delete(parent.children, base)
+	parent.modTime = time.Now()
+	return nil
+}
+
+// Rename moves 'oldname' to 'newname' replacing 'newname' if it exists and
+// isn't a non-empty directory.
+func (m *MemFS) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldParent, oldBase, n, e := m.existing("rename", oldname, false)
+	if e != nil {
+		return e
+	}
+	if oldParent == nil {
+		return memErr("rename", oldname, os.ErrInvalid)
+	}
+
+	newParent, newBase, dst, e := m.lookup("rename", newname, false)
+	switch {
+	case e != nil:
+		return e
+	case newParent == nil:
+		return memErr("rename", newname, os.ErrInvalid)
+	case dst == n:
+		return nil
+	case dst != nil && dst.mode.IsDir() && len(dst.children) > 0:
+		return memErr("rename", newname, errMemNotEmpty)
+	case dst != nil && dst.mode.IsDir() != n.mode.IsDir():
+		return memErr("rename", newname, os.ErrExist)
+	case n.mode.IsDir() && n.contains(newParent):
+		return memErr("rename", newname, os.ErrInvalid)
+	}
+
+	delete(oldParent.children, oldBase)
+	newParent.children[newBase] = n
+
+	now := time.Now()
+	oldParent.modTime, newParent.modTime = now, now
+	return nil
+}
+
+// contains returns true if 'o' is 'n' or is within it.
+func (n *memNode) contains(o *memNode) bool {
+	if n == o {
+		return true
+	}
+	for _, child := range n.children {
+		if child.contains(o) {
+			return true
+		}
+	}
+	return false
+}
+
+// Chmod sets the permission bits of 'name'.
+func (m *MemFS) Chmod(name string, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, n, e := m.existing("chmod", name, true)
+	if e != nil {
+		return e
+	}
+	n.mode = n.mode.Type() | mode.Perm()
+	return nil
+}
+
+// Chtimes sets the modification time of 'name', access times are not
+// recorded.
+func (m *MemFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, n, e := m.existing("chtimes", name, true)
+	if e != nil {
+		return e
+	}
+	n.modTime = mtime
+	return nil
+}
+
+// Symlink creates 'newname' as a symbolic link to 'oldname'.
+func (m *MemFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.fault("symlink", newname); e != nil {
+		return e
+	}
+
+	parent, base, n, e := m.lookup("symlink", newname, false)
+	switch {
+	case e != nil:
+		return e
+	case n != nil:
+		return memErr("symlink", newname, os.ErrExist)
+	}
+
+	parent.children[base] = &memNode{
+		mode:    os.ModeSymlink | os.ModePerm,
+		modTime: time.Now(),
+		target:  filepath.ToSlash(oldname),
+	}
+	parent.modTime = time.Now()
+	return nil
+}
+
+// Readlink returns the target of the symbolic link 'name'.
+func (m *MemFS) Readlink(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, n, e := m.existing("readlink", name, false)
+	if e != nil {
+		return "", e
+	}
+	if n.mode&os.ModeSymlink == 0 {
+		return "", memErr("readlink", name, os.ErrInvalid)
+	}
+	return n.target, nil
+}
+
+// memInfo is a snapshot of a memNode satisfying os.FileInfo. Sys returns the
+// node so SameFileFS can identify the file.
+type memInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	node    *memNode
+}
+
+func newMemInfo(name string, n *memNode) memInfo {
+	size := int64(len(n.data))
+	if n.mode&os.ModeSymlink != 0 {
+		size = int64(len(n.target))
+	}
+	return memInfo{
+		name:    name,
+		size:    size,
+		mode:    n.mode,
+		modTime: n.modTime,
+		node:    n,
+	}
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() os.FileMode  { return i.mode }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memInfo) Sys() interface{}   { return i.node }
+
+// memFile is an open MemFS file.
+type memFile struct {
+	fs     *MemFS
+	name   string
+	node   *memNode
+	flag   int
+	offset int64
+	closed bool
+}
+
+func (f *memFile) check(op string, write bool) error {
+	if e := f.fs.fault(op, f.name); e != nil {
+		return e
+	}
+	if f.closed {
+		return memErr(op, f.name, os.ErrClosed)
+	}
+	if f.node.mode.IsDir() {
+		return memErr(op, f.name, errMemIsDir)
+	}
+
+	writable := f.flag&(os.O_WRONLY|os.O_RDWR) != 0
+	readable := f.flag&os.O_WRONLY == 0
+	if (write && !writable) || (!write && !readable) {
+		return memErr(op, f.name, errMemBadFile)
+	}
+	return nil
+}
+
+func (f *memFile) Name() string {
+	return f.name
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if e := f.check("read", false); e != nil {
+		return 0, e
+	}
+
+	if f.offset >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, f.node.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if e := f.check("write", true); e != nil {
+		return 0, e
+	}
+
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+
+	end := f.offset + int64(len(p))
+	if end > int64(len(f.node.data)) {
+		data := make([]byte, end)
+		copy(data, f.node.data)
+		f.node.data = data
+	}
+
+	copy(f.node.data[f.offset:], p)
+	f.offset = end
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return 0, memErr("seek", f.name, os.ErrClosed)
+	}
+
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	}
+
+	if offset < 0 {
+		return 0, memErr("seek", f.name, os.ErrInvalid)
+	}
+
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return memErr("close", f.name, os.ErrClosed)
+	}
+	f.closed = true
+	return f.fs.fault("close", f.name)
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return newMemInfo(path.Base(cleanMemPath(f.name)), f.node), nil
+}
+
+func (f *memFile) Sync() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return memErr("sync", f.name, os.ErrClosed)
+	}
+	return f.fs.fault("sync", f.name)
+}
+
+func (f *memFile) Chmod(mode os.FileMode) error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return memErr("chmod", f.name, os.ErrClosed)
+	}
+	f.node.mode = f.node.mode.Type() | mode.Perm()
+	return nil
+}
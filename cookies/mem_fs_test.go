@@ -0,0 +1,173 @@
+package cookies_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemFS_Files(t *testing.T) {
+	t.Parallel()
+	m := cookies.NewMemFS()
+
+	require.Nil(t, m.MkdirAll("/a/b", 0750))
+	require.Nil(t, cookies.WriteFileFS(m, "/a/b/abc.txt", []byte("Weatherwax"), 0640))
+
+	info, e := m.Stat("a/b/abc.txt")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, "abc.txt", info.Name())
+	require.Equal(t, int64(10), info.Size())
+	require.Equal(t, os.FileMode(0640), info.Mode())
+
+	mtime := time.Date(2019, 4, 15, 21, 50, 33, 0, time.UTC)
+	require.Nil(t, m.Chmod("/a/b/abc.txt", 0600))
+	require.Nil(t, m.Chtimes("/a/b/abc.txt", mtime, mtime))
+	info, e = m.Stat("/a/b/abc.txt")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, os.FileMode(0600), info.Mode())
+	require.True(t, mtime.Equal(info.ModTime()))
+
+	f, e := m.OpenFile("/a/b/abc.txt", os.O_RDWR|os.O_APPEND, 0)
+	require.Nil(t, e, "%+v", e)
+	_, e = f.Write([]byte(" Esme"))
+	require.Nil(t, e, "%+v", e)
+	_, e = f.Seek(0, io.SeekStart)
+	require.Nil(t, e, "%+v", e)
+	buf := make([]byte, 4)
+	_, e = io.ReadFull(f, buf)
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, "Weat", string(buf))
+	require.Nil(t, f.Close())
+	require.NotNil(t, f.Close())
+	cookiestest.RequireFileFS(t, m, "/a/b/abc.txt", "Weatherwax Esme")
+
+	_, e = m.OpenFile("/a/b/abc.txt", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	require.True(t, os.IsExist(e))
+
+	_, e = m.Open("/a/b/missing.txt")
+	require.True(t, os.IsNotExist(e))
+
+	_, e = m.Open("/a/b/abc.txt/nope")
+	require.NotNil(t, e)
+
+	infos, e := m.ReadDir("/a")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 1, len(infos))
+	require.True(t, infos[0].IsDir())
+	require.Equal(t, os.ModeDir|0750, infos[0].Mode())
+}
+
+func TestMemFS_RemoveAndRename(t *testing.T) {
+	t.Parallel()
+	m := cookies.NewMemFS()
+
+	require.Nil(t, cookies.CreateFilesFS(m, "/", os.ModePerm, map[string][]byte{
+		"a/abc.txt": []byte("Weatherwax"),
+		"b/":        nil,
+	}))
+
+	require.NotNil(t, m.Remove("/a"))
+	require.Nil(t, m.Rename("/a/abc.txt", "/b/xyz.txt"))
+	cookiestest.RequireFileFS(t, m, "/b/xyz.txt", "Weatherwax")
+	require.Nil(t, m.Remove("/a"))
+
+	require.NotNil(t, m.Rename("/b", "/b/c"))
+	require.Nil(t, m.Rename("/b", "/c"))
+	cookiestest.RequireFileFS(t, m, "/c/xyz.txt", "Weatherwax")
+
+	require.Nil(t, m.RemoveAll("/c"))
+	require.Nil(t, m.RemoveAll("/c"))
+
+	ok, e := cookies.FileExistsFS(m, "/c")
+	require.Nil(t, e)
+	require.False(t, ok)
+}
+
+func TestMemFS_Symlinks(t *testing.T) {
+	t.Parallel()
+	m := cookies.NewMemFS()
+
+	require.Nil(t, cookies.CreateFilesFS(m, "/", os.ModePerm, map[string][]byte{
+		"a/b/abc.txt": []byte("Weatherwax"),
+	}))
+	require.Nil(t, m.Symlink("a/b", "/link"))
+	require.Nil(t, m.Symlink("../link/abc.txt", "/a/rel.txt"))
+	require.Nil(t, m.Symlink("/loop", "/loop"))
+
+	cookiestest.RequireFileFS(t, m, "/link/abc.txt", "Weatherwax")
+	cookiestest.RequireFileFS(t, m, "/a/rel.txt", "Weatherwax")
+
+	info, e := m.Lstat("/link")
+	require.Nil(t, e, "%+v", e)
+	require.True(t, info.Mode()&os.ModeSymlink != 0)
+
+	info, e = m.Stat("/link")
+	require.Nil(t, e, "%+v", e)
+	require.True(t, info.IsDir())
+
+	target, e := m.Readlink("/a/rel.txt")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, "../link/abc.txt", target)
+
+	_, e = m.Stat("/loop")
+	require.NotNil(t, e)
+
+	require.Nil(t, cookies.WriteFileFS(m, "/link/new.txt", []byte("Ogg"), 0666))
+	cookiestest.RequireFileFS(t, m, "/a/b/new.txt", "Ogg")
+}
+
+func TestMemFS_DotDotAfterSymlink(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"a/b/abc.txt": []byte("Weatherwax"),
+			"a/xyz.txt":   []byte("Ogg"),
+			"xyz.txt":     []byte("Garlick"),
+		}))
+		require.Nil(t, fsys.Symlink(filepath.Join(root, "a/b"), filepath.Join(root, "link")))
+
+		// '..' leads to the parent of the target not of the link
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(root, "link")+"/../xyz.txt", "Ogg")
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(root, "link")+"/./abc.txt", "Weatherwax")
+
+		require.Nil(t, fsys.MkdirAll(filepath.Join(root, "link")+"/../made", os.ModePerm))
+		ok, e := cookies.IsDirFS(fsys, filepath.Join(root, "a/made"))
+		require.Nil(t, e, "%+v", e)
+		require.True(t, ok)
+
+		_, e = fsys.Stat(filepath.Join(root, "xyz.txt") + "/..")
+		require.NotNil(t, e)
+	})
+}
+
+func TestMemFS_Fail(t *testing.T) {
+	t.Parallel()
+	m := cookies.NewMemFS()
+	exp := errors.New("Octarine")
+
+	require.Nil(t, cookies.WriteFileFS(m, "/abc.txt", []byte("Weatherwax"), 0666))
+
+	m.Fail("open", "/abc.txt", exp)
+	_, e := m.Open("abc.txt")
+	require.NotNil(t, e)
+	require.Equal(t, exp, e.(*os.PathError).Err)
+
+	m.Fail("open", "/abc.txt", nil)
+	cookiestest.RequireFileFS(t, m, "/abc.txt", "Weatherwax")
+
+	m.Fail("sync", "", exp)
+	e = cookies.WriteFileAtomicFS(m, "/abc.txt", []byte("Ogg"), 0666)
+	require.NotNil(t, e)
+	cookiestest.RequireFileFS(t, m, "/abc.txt", "Weatherwax")
+
+	infos, e := m.ReadDir("/")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 1, len(infos), "Temporary file not removed")
+}
@@ -0,0 +1,287 @@
+package cookies
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// utf8BOM is the byte order mark some editors place at the start of UTF-8
+// text.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// LineEnding is the style of line ending used within some text.
+type LineEnding int
+
+const (
+	EndingNone  LineEnding = iota // No line endings, or leave them alone
+	EndingLF                      // Unix style '\n'
+	EndingCRLF                    // Windows style '\r\n'
+	EndingMixed                   // Both LF and CRLF, only ever detected
+)
+
+// String returns the name of the line ending.
+func (le LineEnding) String() string {
+	switch le {
+	case EndingNone:
+		return "none"
+	case EndingLF:
+		return "LF"
+	case EndingCRLF:
+		return "CRLF"
+	case EndingMixed:
+		return "mixed"
+	default:
+		return "unknown"
+	}
+}
+
+func (le LineEnding) bytes() string {
+	if le == EndingCRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// BOMAction states what to do with a UTF-8 byte order mark.
+type BOMAction int
+
+const (
+	BOMKeep  BOMAction = iota // Leave as is
+	BOMStrip                  // Remove if present
+	BOMAdd                    // Add if missing
+)
+
+// NormaliseOptions configures NormaliseText, NormaliseFile, and
+// NormaliseDir. Check is ignored by NormaliseText and Walk is only used by
+// NormaliseDir.
+type NormaliseOptions struct {
+	LineEnding   LineEnding  // EndingLF or EndingCRLF, left alone if EndingNone
+	BOM          BOMAction   // What to do with the UTF-8 BOM
+	TrimTrailing bool        // Remove spaces and tabs from the end of lines
+	Markdown     bool        // Keep hard line breaks, two or more trailing spaces, when trimming
+	FinalNewline bool        // Ensure non empty text ends with a line ending
+	Check        bool        // Report issues without changing any files
+	Walk         WalkOptions // Selects the files normalised
+}
+
+// NormaliseResult is a file that was, or in check mode would be, changed.
+type NormaliseResult struct {
+	Path   string   `json:"path"`   // Relative to the root separated by '/'
+	Issues []string `json:"issues"` // Such as 'trailing whitespace'
+}
+
+// HasBOM returns true if 'data' starts with a UTF-8 byte order mark.
+func HasBOM(data []byte) bool {
+	return bytes.HasPrefix(data, utf8BOM)
+}
+
+// DetectLineEnding returns the style of line ending used within 'data'. A
+// lone '\r' is not considered a line ending.
+func DetectLineEnding(data []byte) LineEnding {
+	crlf := bytes.Count(data, []byte("\r\n"))
+	lf := bytes.Count(data, []byte("\n")) - crlf
+
+	switch {
+	case crlf > 0 && lf > 0:
+		return EndingMixed
+	case crlf > 0:
+		return EndingCRLF
+	case lf > 0:
+		return EndingLF
+	default:
+		return EndingNone
+	}
+}
+
+// NormaliseText returns 'data' normalised according to 'opts' along with a
+// description of each kind of change made. 'data' is returned untouched if
+// no changes were needed.
+func NormaliseText(data []byte, opts NormaliseOptions) ([]byte, []string) {
+
+	var issues []string
+	text := string(data)
+
+	hasBOM := HasBOM(data)
+	if hasBOM {
+		text = text[len(utf8BOM):]
+	}
+
+	switch {
+	case opts.BOM == BOMStrip && hasBOM:
+		issues = append(issues, "UTF-8 BOM")
+		hasBOM = false
+	case opts.BOM == BOMAdd && !hasBOM:
+		issues = append(issues, "missing UTF-8 BOM")
+		hasBOM = true
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	var trimmed, wrongEnding bool
+
+	for i, line := range lines {
+		body, ending := splitLineEnding(line)
+
+		if opts.TrimTrailing {
+			t := strings.TrimRight(body, " \t")
+			if t != body && !(opts.Markdown && isHardBreak(body, t, lines[i+1:])) {
+				body, trimmed = t, true
+			}
+		}
+
+		if opts.LineEnding != EndingNone && ending != "" && ending != opts.LineEnding.bytes() {
+			ending, wrongEnding = opts.LineEnding.bytes(), true
+		}
+
+		lines[i] = body + ending
+	}
+
+	if trimmed {
+		issues = append(issues, "trailing whitespace")
+	}
+	if wrongEnding {
+		issues = append(issues, "line endings not "+opts.LineEnding.String())
+	}
+
+	if last := lines[len(lines)-1]; opts.FinalNewline && last != "" {
+		lines[len(lines)-1] = last + finalLineEnding(text, opts.LineEnding)
+		issues = append(issues, "no final newline")
+	}
+
+	if len(issues) == 0 {
+		return data, nil
+	}
+
+	sb := strings.Builder{}
+	if hasBOM {
+		sb.Write(utf8BOM)
+	}
+	for _, line := range lines {
+		sb.WriteString(line)
+	}
+	return []byte(sb.String()), issues
+}
+
+// isHardBreak returns true if the line 'body', which is 't' once trimmed,
+// ends with a Markdown hard line break. Trailing spaces only break a line if
+// text follows within the same paragraph, i.e. the next of 'rest'.
+func isHardBreak(body, t string, rest []string) bool {
+	trailing := body[len(t):]
+	if t == "" || len(trailing) < 2 || strings.Trim(trailing, " ") != "" {
+		return false
+	}
+	return len(rest) > 0 && strings.TrimSpace(rest[0]) != ""
+}
+
+func splitLineEnding(line string) (body, ending string) {
+	switch {
+	case strings.HasSuffix(line, "\r\n"):
+		return line[:len(line)-2], "\r\n"
+	case strings.HasSuffix(line, "\n"):
+		return line[:len(line)-1], "\n"
+	default:
+		return line, ""
+	}
+}
+
+// finalLineEnding returns the line ending to append to 'text', the
+// requested one if given else the first one used within 'text'.
+func finalLineEnding(text string, le LineEnding) string {
+	if le != EndingNone {
+		return le.bytes()
+	}
+	if i := strings.IndexByte(text, '\n'); i > 0 && text[i-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// NormaliseFile normalises the text file 'f', as NormaliseText does,
+// replacing it atomically with its mode kept if any changes were needed. The
+// issues found are returned. In check mode 'f' is never changed. Files named
+// '*.md' or '*.markdown' always have opts.Markdown set so their hard line
+// breaks survive.
+func NormaliseFile(f string, opts NormaliseOptions) ([]string, error) {
+	return NormaliseFileFS(OSFS, f, opts)
+}
+
+// NormaliseFileFS is NormaliseFile for any FS.
+func NormaliseFileFS(fsys FS, f string, opts NormaliseOptions) ([]string, error) {
+	data, e := ReadFileFS(fsys, f)
+	if e != nil {
+		return nil, e
+	}
+	return normaliseFile(fsys, f, data, opts)
+}
+
+func normaliseFile(fsys FS, f string, data []byte, opts NormaliseOptions) ([]string, error) {
+
+	switch strings.ToLower(filepath.Ext(f)) {
+	case ".md", ".markdown":
+		opts.Markdown = true
+	}
+
+	result, issues := NormaliseText(data, opts)
+	if len(issues) == 0 || opts.Check {
+		return issues, nil
+	}
+
+	stat, e := fsys.Stat(f)
+	if e != nil {
+		return nil, e
+	}
+
+	e = writeAtomic(fsys, f, stat.Mode().Perm(), true, func(w io.Writer) error {
+		_, e := w.Write(result)
+		return e
+	})
+	if e != nil {
+		return nil, Wrap(e, "Failed to normalise %s", f)
+	}
+
+	return issues, nil
+}
+
+// NormaliseDir normalises every text file, selected by opts.Walk, within the
+// tree 'root', as NormaliseFile does. Binary files, those containing a NUL
+// byte or invalid UTF-8, are skipped. The files that were changed are
+// returned in walk order. In check mode nothing is changed and the files that
+// would be are returned.
+func NormaliseDir(root string, opts NormaliseOptions) ([]NormaliseResult, error) {
+	return NormaliseDirFS(OSFS, root, opts)
+}
+
+// NormaliseDirFS is NormaliseDir for any FS.
+func NormaliseDirFS(fsys FS, root string, opts NormaliseOptions) ([]NormaliseResult, error) {
+
+	results := []NormaliseResult{}
+	walk := opts.Walk
+	walk.Dirs = false
+
+	e := WalkFS(fsys, root, walk, func(entry WalkEntry) error {
+		if !entry.Info.Mode().IsRegular() {
+			return nil
+		}
+
+		data, e := ReadFileFS(fsys, entry.Path)
+		if e != nil {
+			return e
+		}
+		if isBinary(data) {
+			return nil
+		}
+
+		issues, e := normaliseFile(fsys, entry.Path, data, opts)
+		if e != nil {
+			return e
+		}
+
+		if len(issues) > 0 {
+			results = append(results, NormaliseResult{Path: entry.Rel, Issues: issues})
+		}
+		return nil
+	})
+
+	return results, e
+}
@@ -0,0 +1,147 @@
+package cookies_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLineEnding(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, cookies.EndingNone, cookies.DetectLineEnding([]byte("Weatherwax\r")))
+	require.Equal(t, cookies.EndingLF, cookies.DetectLineEnding([]byte("Esme\nWeatherwax\n")))
+	require.Equal(t, cookies.EndingCRLF, cookies.DetectLineEnding([]byte("Esme\r\nWeatherwax")))
+	require.Equal(t, cookies.EndingMixed, cookies.DetectLineEnding([]byte("Esme\r\nWeatherwax\n")))
+	require.Equal(t, "CRLF", cookies.EndingCRLF.String())
+}
+
+func TestNormaliseText(t *testing.T) {
+	t.Parallel()
+
+	all := cookies.NormaliseOptions{
+		LineEnding:   cookies.EndingLF,
+		BOM:          cookies.BOMStrip,
+		TrimTrailing: true,
+		FinalNewline: true,
+	}
+
+	in := "\xEF\xBB\xBFEsme \t\r\nWeatherwax\n\nOgg  "
+	act, issues := cookies.NormaliseText([]byte(in), all)
+	require.Equal(t, "Esme\nWeatherwax\n\nOgg\n", string(act))
+	require.Equal(t, []string{
+		"UTF-8 BOM",
+		"trailing whitespace",
+		"line endings not LF",
+		"no final newline",
+	}, issues)
+
+	act, issues = cookies.NormaliseText(act, all)
+	require.Equal(t, "Esme\nWeatherwax\n\nOgg\n", string(act))
+	require.Empty(t, issues)
+
+	act, issues = cookies.NormaliseText([]byte("Esme\r\nWeatherwax"), cookies.NormaliseOptions{
+		BOM:          cookies.BOMAdd,
+		FinalNewline: true,
+	})
+	require.Equal(t, "\xEF\xBB\xBFEsme\r\nWeatherwax\r\n", string(act))
+	require.Equal(t, []string{"missing UTF-8 BOM", "no final newline"}, issues)
+
+	act, issues = cookies.NormaliseText([]byte("Esme\nWeatherwax\n"), cookies.NormaliseOptions{LineEnding: cookies.EndingCRLF})
+	require.Equal(t, "Esme\r\nWeatherwax\r\n", string(act))
+	require.Equal(t, []string{"line endings not CRLF"}, issues)
+
+	act, issues = cookies.NormaliseText(nil, all)
+	require.Empty(t, act)
+	require.Empty(t, issues)
+
+	md := all
+	md.Markdown = true
+	in = "Esme  \nWeatherwax \nOgg\t\t\nNanny  \n\nMagrat  \n"
+	act, issues = cookies.NormaliseText([]byte(in), md)
+	require.Equal(t, "Esme  \nWeatherwax\nOgg\nNanny\n\nMagrat\n", string(act))
+	require.Equal(t, []string{"trailing whitespace"}, issues)
+}
+
+func TestNormaliseDir(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		"good.txt":      []byte("Weatherwax\n"),
+		"crlf.txt":      []byte("Esme\r\nWeatherwax\r\n"),
+		"a/trailing.md": []byte("Ogg \n"),
+		"a/break.md":    []byte("Ogg  \nNanny\n"),
+		"image.bin":     {0, '\r', '\n', ' '},
+		"skip/bad.txt":  []byte("Garlick "),
+	}))
+	require.Nil(t, os.Chmod(filepath.Join(temp, "crlf.txt"), 0640))
+
+	opts := cookies.NormaliseOptions{
+		LineEnding:   cookies.EndingLF,
+		TrimTrailing: true,
+		FinalNewline: true,
+		Check:        true,
+		Walk:         cookies.WalkOptions{Exclude: []string{"skip"}},
+	}
+
+	exp := []cookies.NormaliseResult{
+		{Path: "a/trailing.md", Issues: []string{"trailing whitespace"}},
+		{Path: "crlf.txt", Issues: []string{"line endings not LF"}},
+	}
+
+	act, e := cookies.NormaliseDir(temp, opts)
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, exp, act)
+	cookiestest.RequireFile(t, filepath.Join(temp, "crlf.txt"), "Esme\r\nWeatherwax\r\n")
+
+	opts.Check = false
+	act, e = cookies.NormaliseDir(temp, opts)
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, exp, act)
+
+	cookiestest.RequireFile(t, filepath.Join(temp, "crlf.txt"), "Esme\nWeatherwax\n")
+	cookiestest.RequireFile(t, filepath.Join(temp, "a/trailing.md"), "Ogg\n")
+	cookiestest.RequireFile(t, filepath.Join(temp, "a/break.md"), "Ogg  \nNanny\n")
+	cookiestest.RequireFile(t, filepath.Join(temp, "skip/bad.txt"), "Garlick ")
+
+	bin, e := ioutil.ReadFile(filepath.Join(temp, "image.bin"))
+	require.Nil(t, e)
+	require.Equal(t, []byte{0, '\r', '\n', ' '}, bin)
+
+	stat, e := os.Stat(filepath.Join(temp, "crlf.txt"))
+	require.Nil(t, e)
+	require.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+
+	act, e = cookies.NormaliseDir(temp, opts)
+	require.Nil(t, e, "%+v", e)
+	require.Empty(t, act)
+}
+
+func TestNormaliseDirFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"good.txt": []byte("Weatherwax\n"),
+			"crlf.txt": []byte("Esme\r\nWeatherwax\r\n"),
+		}))
+
+		opts := cookies.NormaliseOptions{LineEnding: cookies.EndingLF, FinalNewline: true}
+		act, e := cookies.NormaliseDirFS(fsys, root, opts)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []cookies.NormaliseResult{
+			{Path: "crlf.txt", Issues: []string{"line endings not LF"}},
+		}, act)
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(root, "crlf.txt"), "Esme\nWeatherwax\n")
+
+		f := filepath.Join(root, "good.txt")
+		require.Nil(t, cookies.WriteFileFS(fsys, f, []byte("Ogg"), 0666))
+		issues, e := cookies.NormaliseFileFS(fsys, f, opts)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []string{"no final newline"}, issues)
+		cookiestest.RequireFileFS(t, fsys, f, "Ogg\n")
+	})
+}
@@ -0,0 +1,52 @@
+package cookies
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// ErrRootNotFound is the cause of the error returned by FindRoot when no
+// directory contains any of the marker files.
+var ErrRootNotFound = errors.New("Root directory not found")
+
+// FindRoot walks up from the directory 'dir' to find the nearest directory,
+// including 'dir' itself, that contains any of the files or directories named
+// within 'markers', e.g. 'go.mod' or '.git'. The absolute path of the root is
+// returned along with the path of 'dir' relative to it.
+func FindRoot(dir string, markers ...string) (root, rel string, e error) {
+	abs, e := filepath.Abs(dir)
+	if e != nil {
+		return "", "", e
+	}
+	return FindRootFS(OSFS, abs, markers...)
+}
+
+// FindRootFS is FindRoot for any FS. Not every FS has a working directory so
+// 'dir' must be absolute.
+func FindRootFS(fsys FS, dir string, markers ...string) (root, rel string, e error) {
+
+	if !filepath.IsAbs(dir) {
+		return "", "", fmt.Errorf("Not an absolute path: %s", dir)
+	}
+	abs := filepath.Clean(dir)
+
+	for d := abs; ; {
+		for _, m := range markers {
+			ok, e := FileExistsFS(fsys, filepath.Join(d, m))
+			if e != nil {
+				return "", "", e
+			}
+			if ok {
+				rel, e = filepath.Rel(d, abs)
+				return d, rel, e
+			}
+		}
+
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", "", Wrap(ErrRootNotFound, "No %v found above %s", markers, abs)
+		}
+		d = parent
+	}
+}
@@ -0,0 +1,61 @@
+package cookies_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindRoot(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	proj := filepath.Join(temp, "proj")
+	require.Nil(t, cookies.CreateFiles(proj, os.ModePerm, map[string][]byte{
+		"go.mod":       []byte("module proj"),
+		"a/b/.git/":    nil,
+		"a/b/c/d.txt":  []byte("Weatherwax"),
+		"x/y/z/empty/": nil,
+	}))
+
+	root, rel, e := cookies.FindRoot(filepath.Join(proj, "x/y/z"), "go.mod")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, proj, root)
+	require.Equal(t, filepath.FromSlash("x/y/z"), rel)
+
+	root, rel, e = cookies.FindRoot(filepath.Join(proj, "a/b/c"), "go.mod", ".git")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, filepath.Join(proj, "a/b"), root)
+	require.Equal(t, "c", rel)
+
+	root, rel, e = cookies.FindRoot(proj, "go.mod")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, proj, root)
+	require.Equal(t, ".", rel)
+
+	_, _, e = cookies.FindRoot(proj, "no-such-marker.d2c8e1")
+	require.True(t, errors.Is(e, cookies.ErrRootNotFound), "%+v", e)
+}
+
+func TestFindRootFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		proj := filepath.Join(root, "proj")
+		require.Nil(t, cookies.CreateFilesFS(fsys, proj, os.ModePerm, map[string][]byte{
+			"go.mod":   []byte("module proj"),
+			"a/b/c/d/": nil,
+		}))
+
+		act, rel, e := cookies.FindRootFS(fsys, filepath.Join(proj, "a/b"), "go.mod")
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, proj, act)
+		require.Equal(t, filepath.FromSlash("a/b"), rel)
+
+		_, _, e = cookies.FindRootFS(fsys, "proj", "go.mod")
+		require.NotNil(t, e)
+	})
+}
@@ -0,0 +1,301 @@
+package cookies
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateTimeFormat is the suffix layout of rotated files, it sorts
+// chronologically and is valid on all common file systems.
+const rotateTimeFormat = "2006-01-02T15-04-05.000000000"
+
+// RotateOptions configures a RotateWriter.
+type RotateOptions struct {
+	MaxSize  int64         // Roll over before the file exceeds this many bytes, never if zero
+	Interval time.Duration // Roll over once the file has been open this long, never if zero
+	Backups  int           // Number of rotated files kept, all if zero
+	Gzip     bool          // Compress rotated files
+	Mode     os.FileMode   // Mode of created files, 0666 if zero
+	OnError  func(error)   // Given errors rolling over that didn't stop a write, may be nil
+}
+
+// RotateWriter is an io.WriteCloser that appends to a log file, rolling it
+// over when it grows too large or too old. The rotated file is renamed with a
+// UTC timestamp suffix, e.g. 'app.log.2019-04-15T21-50-33.000000000', and
+// optionally gzipped adding a '.gz' suffix. Only the newest opts.Backups
+// rotated files are kept.
+//
+// It is safe for concurrent use. A single write is never split across files
+// so a write larger than opts.MaxSize produces an oversized file.
+type RotateWriter struct {
+	mu     sync.Mutex
+	fsys   FS
+	path   string
+	opts   RotateOptions
+	file   File
+	size   int64
+	opened time.Time
+	now    func() time.Time
+}
+
+// NewRotateWriter returns a new RotateWriter appending to the file 'f',
+// creating it and its parent directories if missing.
+func NewRotateWriter(f string, opts RotateOptions) (*RotateWriter, error) {
+	return NewRotateWriterFS(OSFS, f, opts)
+}
+
+// NewRotateWriterFS is NewRotateWriter for any FS.
+func NewRotateWriterFS(fsys FS, f string, opts RotateOptions) (*RotateWriter, error) {
+	if opts.Mode == 0 {
+		opts.Mode = 0666
+	}
+
+	w := &RotateWriter{fsys: fsys, path: f, opts: opts, now: time.Now}
+	if e := w.open(); e != nil {
+		return nil, e
+	}
+	return w, nil
+}
+
+// Write appends 'p' to the file rolling it over first if needed. If rolling
+// over fails but a file is still open, e.g. the rolled over file couldn't be
+// compressed, then the write still happens and the error is given to
+// opts.OnError once the writer is unlocked.
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	n, rotateErr, e := w.write(p)
+	if rotateErr != nil && w.opts.OnError != nil {
+		w.opts.OnError(rotateErr)
+	}
+	return n, e
+}
+
+func (w *RotateWriter) write(p []byte) (n int, rotateErr, e error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, nil, os.ErrClosed
+	}
+
+	if w.due(len(p)) {
+		if rotateErr = w.rotate(); w.file == nil {
+			return 0, nil, rotateErr
+		}
+	}
+
+	n, e = w.file.Write(p)
+	w.size += int64(n)
+	return n, rotateErr, e
+}
+
+// Rotate rolls the file over immediately.
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen closes then reopens the file, creating it if missing. Use it after
+// the file has been moved or rotated by another program, e.g. logrotate.
+func (w *RotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil {
+		f := w.file
+		w.file = nil
+		if e := f.Close(); e != nil {
+			return e
+		}
+	}
+	return w.open()
+}
+
+// Close closes the file, further writes fail.
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+
+	f := w.file
+	w.file = nil
+	return f.Close()
+}
+
+// Backups returns the paths of the rotated files, oldest first.
+func (w *RotateWriter) Backups() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.backups()
+}
+
+func (w *RotateWriter) open() error {
+	if e := w.fsys.MkdirAll(filepath.Dir(w.path), os.ModePerm); e != nil {
+		return e
+	}
+
+	f, e := w.fsys.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, w.opts.Mode)
+	if e != nil {
+		return e
+	}
+
+	info, e := f.Stat()
+	if e != nil {
+		f.Close()
+		return e
+	}
+
+	w.file, w.size, w.opened = f, info.Size(), w.now()
+	return nil
+}
+
+// due returns true if the file should be rolled over before writing 'n'
+// bytes. An empty file is never rolled over, instead its interval restarts.
+func (w *RotateWriter) due(n int) bool {
+	if w.opts.MaxSize > 0 && w.size > 0 && w.size+int64(n) > w.opts.MaxSize {
+		return true
+	}
+
+	if w.opts.Interval <= 0 || w.now().Sub(w.opened) < w.opts.Interval {
+		return false
+	}
+
+	if w.size == 0 {
+		w.opened = w.now()
+		return false
+	}
+	return true
+}
+
+// rotate closes and renames the file, opens a new one, then compresses and
+// prunes the backups. If the new file can't be opened then w.file is nil.
+func (w *RotateWriter) rotate() error {
+
+	f := w.file
+	w.file = nil
+	if e := f.Close(); e != nil {
+		return e
+	}
+
+	backup, e := w.backupName()
+	if e == nil {
+		e = w.fsys.Rename(w.path, backup)
+	}
+	if e != nil {
+		if e2 := w.open(); e2 != nil {
+			return e2
+		}
+		return e
+	}
+
+	if e := w.open(); e != nil {
+		return e
+	}
+
+	if w.opts.Gzip {
+		if e := w.compress(backup); e != nil {
+			return Wrap(e, "Failed to compress %s", backup)
+		}
+	}
+
+	return w.prune()
+}
+
+// backupName returns an unused name for the next rotated file.
+func (w *RotateWriter) backupName() (string, error) {
+	for t := w.now().UTC(); ; t = t.Add(time.Nanosecond) {
+		name := w.path + "." + t.Format(rotateTimeFormat)
+
+		ok, e := FileExistsFS(w.fsys, name)
+		if e != nil {
+			return "", e
+		}
+		if !ok {
+			if ok, e = FileExistsFS(w.fsys, name+".gz"); e != nil || !ok {
+				return name, e
+			}
+		}
+	}
+}
+
+func (w *RotateWriter) compress(f string) error {
+	src, e := w.fsys.Open(f)
+	if e != nil {
+		return e
+	}
+	defer src.Close()
+
+	e = WriteAtomicFS(w.fsys, f+".gz", w.opts.Mode, func(dst io.Writer) error {
+		gw := gzip.NewWriter(dst)
+		if _, e := io.Copy(gw, src); e != nil {
+			return e
+		}
+		return gw.Close()
+	})
+	if e != nil {
+		return e
+	}
+
+	return w.fsys.Remove(f)
+}
+
+// prune removes the oldest backups beyond the number to keep.
+func (w *RotateWriter) prune() error {
+	if w.opts.Backups <= 0 {
+		return nil
+	}
+
+	backups, e := w.backups()
+	if e != nil {
+		return e
+	}
+
+	for len(backups) > w.opts.Backups {
+		if e := w.fsys.Remove(backups[0]); e != nil && !os.IsNotExist(e) {
+			return e
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+func (w *RotateWriter) backups() ([]string, error) {
+	dir, base := filepath.Split(w.path)
+	if dir == "" {
+		dir = "."
+	}
+
+	infos, e := w.fsys.ReadDir(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	var backups []string
+	for _, info := range infos {
+		name := info.Name()
+		if !info.Mode().IsRegular() || !strings.HasPrefix(name, base+".") {
+			continue
+		}
+
+		suffix := strings.TrimSuffix(name[len(base)+1:], ".gz")
+		if _, e := time.Parse(rotateTimeFormat, suffix); e == nil {
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+
+	sort.Strings(backups)
+	return backups, nil
+}
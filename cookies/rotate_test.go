@@ -0,0 +1,218 @@
+package cookies_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+// testClock is a fake clock that only moves when told to.
+type testClock struct {
+	t time.Time
+}
+
+func (c *testClock) now() time.Time {
+	return c.t
+}
+
+func newTestRotateWriter(t *testing.T, fsys cookies.FS, f string, opts cookies.RotateOptions) (*cookies.RotateWriter, *testClock) {
+	w, e := cookies.NewRotateWriterFS(fsys, f, opts)
+	require.Nil(t, e, "%+v", e)
+	c := &testClock{time.Date(2019, 4, 15, 21, 50, 33, 0, time.UTC)}
+	w.SetClock(c.now)
+	return w, c
+}
+
+func requireBackups(t *testing.T, fsys cookies.FS, w *cookies.RotateWriter, exps ...string) {
+	backups, e := w.Backups()
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, len(exps), len(backups), "%v", backups)
+
+	for i, f := range backups {
+		data, e := cookies.ReadFileFS(fsys, f)
+		require.Nil(t, e, "%+v", e)
+
+		if strings.HasSuffix(f, ".gz") {
+			r, e := gzip.NewReader(bytes.NewReader(data))
+			require.Nil(t, e, "%+v", e)
+			data, e = ioutil.ReadAll(r)
+			require.Nil(t, e, "%+v", e)
+		}
+
+		require.Equal(t, exps[i], string(data))
+	}
+}
+
+func TestRotateWriter_Size(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		f := filepath.Join(root, "logs", "app.log")
+		w, _ := newTestRotateWriter(t, fsys, f, cookies.RotateOptions{MaxSize: 10, Backups: 2})
+
+		for _, s := range []string{"Weatherwax", "Ogg", "Garlick", "Nanny", "Tiffany"} {
+			n, e := w.Write([]byte(s))
+			require.Nil(t, e, "%+v", e)
+			require.Equal(t, len(s), n)
+		}
+
+		cookiestest.RequireFileFS(t, fsys, f, "Tiffany")
+		requireBackups(t, fsys, w, "OggGarlick", "Nanny")
+		require.Nil(t, w.Close())
+
+		_, e := w.Write([]byte("Esme"))
+		require.NotNil(t, e)
+
+		w, c := newTestRotateWriter(t, fsys, f, cookies.RotateOptions{MaxSize: 10})
+		c.t = c.t.Add(time.Hour)
+		_, e = w.Write([]byte("Ogg"))
+		require.Nil(t, e, "%+v", e)
+		_, e = w.Write([]byte("Ogg"))
+		require.Nil(t, e, "%+v", e)
+		cookiestest.RequireFileFS(t, fsys, f, "Ogg")
+		requireBackups(t, fsys, w, "OggGarlick", "Nanny", "TiffanyOgg")
+		require.Nil(t, w.Close())
+	})
+}
+
+func TestRotateWriter_IntervalAndGzip(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		f := filepath.Join(root, "app.log")
+		w, c := newTestRotateWriter(t, fsys, f, cookies.RotateOptions{
+			Interval: time.Hour,
+			Gzip:     true,
+		})
+
+		for _, s := range []string{"a", "b", "c", "d"} {
+			_, e := w.Write([]byte(s))
+			require.Nil(t, e, "%+v", e)
+			c.t = c.t.Add(30 * time.Minute)
+		}
+
+		cookiestest.RequireFileFS(t, fsys, f, "cd")
+		requireBackups(t, fsys, w, "ab")
+
+		require.Nil(t, w.Rotate())
+		cookiestest.RequireFileFS(t, fsys, f, "")
+		requireBackups(t, fsys, w, "ab", "cd")
+		require.Nil(t, w.Close())
+	})
+}
+
+func TestRotateWriter_IntervalEmpty(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		f := filepath.Join(root, "app.log")
+		w, c := newTestRotateWriter(t, fsys, f, cookies.RotateOptions{Interval: time.Hour})
+
+		// Nothing to back up so the interval restarts on the first write
+		c.t = c.t.Add(2 * time.Hour)
+		for _, s := range []string{"a", "b"} {
+			_, e := w.Write([]byte(s))
+			require.Nil(t, e, "%+v", e)
+			c.t = c.t.Add(30 * time.Minute)
+		}
+
+		cookiestest.RequireFileFS(t, fsys, f, "ab")
+		requireBackups(t, fsys, w)
+		require.Nil(t, w.Close())
+	})
+}
+
+func TestRotateWriter_OnError(t *testing.T) {
+	t.Parallel()
+
+	m := cookies.NewMemFS()
+	var errs []error
+	w, _ := newTestRotateWriter(t, m, "/app.log", cookies.RotateOptions{
+		MaxSize: 4,
+		OnError: func(e error) {
+			errs = append(errs, e)
+		},
+	})
+
+	exp := errors.New("Octarine")
+	m.Fail("rename", "/app.log", exp)
+
+	for _, s := range []string{"Ogg", "Nanny"} {
+		n, e := w.Write([]byte(s))
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, len(s), n)
+	}
+
+	require.Equal(t, 1, len(errs))
+	require.True(t, errors.Is(errs[0], exp), "%+v", errs[0])
+	cookiestest.RequireFileFS(t, m, "/app.log", "OggNanny")
+	requireBackups(t, m, w)
+	require.Nil(t, w.Close())
+}
+
+func TestRotateWriter_Reopen(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		f := filepath.Join(root, "app.log")
+		w, _ := newTestRotateWriter(t, fsys, f, cookies.RotateOptions{})
+
+		_, e := w.Write([]byte("Weatherwax"))
+		require.Nil(t, e, "%+v", e)
+
+		require.Nil(t, fsys.Rename(f, f+".1"))
+		require.Nil(t, w.Reopen())
+		_, e = w.Write([]byte("Ogg"))
+		require.Nil(t, e, "%+v", e)
+
+		cookiestest.RequireFileFS(t, fsys, f+".1", "Weatherwax")
+		cookiestest.RequireFileFS(t, fsys, f, "Ogg")
+		requireBackups(t, fsys, w)
+		require.Nil(t, w.Close())
+	})
+}
+
+func TestRotateWriter_Concurrent(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "app.log")
+	w, e := cookies.NewRotateWriter(f, cookies.RotateOptions{MaxSize: 64})
+	require.Nil(t, e, "%+v", e)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				_, e := fmt.Fprintf(w, "%d:%02d\n", i, j)
+				require.Nil(t, e, "%+v", e)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.Nil(t, w.Close())
+
+	backups, e := w.Backups()
+	require.Nil(t, e, "%+v", e)
+
+	lines := 0
+	for _, b := range append(backups, f) {
+		data, e := ioutil.ReadFile(b)
+		require.Nil(t, e, "%+v", e)
+		require.True(t, len(data) <= 64, "%s has %d bytes", b, len(data))
+		lines += strings.Count(string(data), "\n")
+	}
+	require.Equal(t, 200, lines)
+
+	_, e = os.Stat(f)
+	require.Nil(t, e)
+}
@@ -0,0 +1,154 @@
+package cookies
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPathEscapes is the cause of the errors returned by SafeJoin and
+// SafeResolve when a path would lead outside of its root.
+var ErrPathEscapes = errors.New("Path escapes root")
+
+// maxSafeLinks is the number of symbolic links SafeResolve follows before
+// giving up, the same limit Linux imposes.
+const maxSafeLinks = 40
+
+// SafeJoin joins the relative path 'p' onto 'root' returning an error
+// wrapping ErrPathEscapes if 'p' is absolute or its '..' elements would lead
+// outside of 'root'. Only the path text is inspected, use SafeResolve if
+// symbolic links within 'root' may be untrusted.
+func SafeJoin(root, p string) (string, error) {
+	rel, e := safeRel(p)
+	if e != nil {
+		return "", e
+	}
+	return filepath.Join(root, rel), nil
+}
+
+// SafeResolve is SafeJoin except existing symbolic links along the path are
+// followed, as the operating system would, before any '..' after them is
+// applied. An error wrapping ErrPathEscapes is returned if any of the links
+// lead outside of 'root'. The returned path has the links within 'root'
+// resolved. Elements that don't exist yet are joined as they are so the
+// result may be used to create files.
+func SafeResolve(root, p string) (string, error) {
+	return SafeResolveFS(OSFS, root, p)
+}
+
+// SafeResolveFS is SafeResolve for any FS.
+func SafeResolveFS(fsys FS, root, p string) (string, error) {
+
+	if e := safeAbs(p); e != nil {
+		return "", e
+	}
+
+	var resolved []string // Elements, relative to root, resolved so far
+	pending := splitSafePath(p)
+	links := 0
+	missing := -1 // Index within resolved of the first element not existing
+
+	for len(pending) > 0 {
+		elem := pending[0]
+		pending = pending[1:]
+
+		if elem == ".." {
+			if len(resolved) == 0 {
+				return "", safeEscapeErr(p, root)
+			}
+			resolved = resolved[:len(resolved)-1]
+			if len(resolved) <= missing {
+				missing = -1
+			}
+			continue
+		}
+
+		if missing >= 0 {
+			resolved = append(resolved, elem)
+			continue
+		}
+
+		cur := filepath.Join(append([]string{root}, append(resolved, elem)...)...)
+		info, e := fsys.Lstat(cur)
+		if os.IsNotExist(e) {
+			missing = len(resolved)
+		} else if e != nil {
+			return "", e
+		}
+
+		if missing >= 0 || info.Mode()&os.ModeSymlink == 0 {
+			resolved = append(resolved, elem)
+			continue
+		}
+
+		if links++; links > maxSafeLinks {
+			return "", &os.PathError{Op: "resolve", Path: p, Err: errors.New("Too many symbolic links")}
+		}
+
+		target, e := fsys.Readlink(cur)
+		if e != nil {
+			return "", e
+		}
+
+		if filepath.IsAbs(target) {
+			rel, e := safeAbsRel(root, target)
+			if e != nil {
+				return "", safeEscapeErr(p, root)
+			}
+			resolved, target = nil, rel
+		}
+
+		pending = append(splitSafePath(target), pending...)
+	}
+
+	return filepath.Join(append([]string{root}, resolved...)...), nil
+}
+
+// safeRel returns the cleaned form of the relative path 'p' or an error if
+// it's absolute or escapes lexically.
+func safeRel(p string) (string, error) {
+	if e := safeAbs(p); e != nil {
+		return "", e
+	}
+
+	rel := filepath.Clean(p)
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", Wrap(ErrPathEscapes, "%s leads outside of its root", p)
+	}
+	return rel, nil
+}
+
+func safeAbs(p string) error {
+	if filepath.IsAbs(p) || filepath.VolumeName(p) != "" || strings.HasPrefix(p, "/") {
+		return Wrap(ErrPathEscapes, "Absolute path %s", p)
+	}
+	return nil
+}
+
+// safeAbsRel returns the absolute link 'target' relative to 'root' or an error
+// if it's outside of 'root'.
+func safeAbsRel(root, target string) (string, error) {
+	abs, e := filepath.Abs(root)
+	if e != nil {
+		return "", e
+	}
+	if in, e := isWithin(abs, target); e != nil || !in {
+		return "", ErrPathEscapes
+	}
+	return filepath.Rel(abs, target)
+}
+
+func splitSafePath(p string) []string {
+	var elems []string
+	for _, elem := range strings.Split(filepath.ToSlash(p), "/") {
+		if elem != "" && elem != "." {
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
+
+func safeEscapeErr(p, root string) error {
+	return Wrap(ErrPathEscapes, "%s leads outside of %s", p, root)
+}
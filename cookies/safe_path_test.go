@@ -0,0 +1,66 @@
+package cookies_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeJoin(t *testing.T) {
+	t.Parallel()
+
+	act, e := cookies.SafeJoin("root", "a/../b/./c.txt")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, filepath.FromSlash("root/b/c.txt"), act)
+
+	act, e = cookies.SafeJoin("root", "")
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, "root", act)
+
+	for _, p := range []string{"..", "../a", "a/../../b", "/etc/passwd"} {
+		_, e = cookies.SafeJoin("root", p)
+		require.True(t, errors.Is(e, cookies.ErrPathEscapes), "%s: %+v", p, e)
+	}
+}
+
+func TestSafeResolve(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"a/b/abc.txt": []byte("Weatherwax"),
+			"outside/":    nil,
+		}))
+
+		dir := filepath.Join(root, "a")
+		require.Nil(t, fsys.Symlink("b", filepath.Join(dir, "in")))
+		require.Nil(t, fsys.Symlink(filepath.Join(dir, "b"), filepath.Join(dir, "abs")))
+		require.Nil(t, fsys.Symlink(".", filepath.Join(dir, "self")))
+		require.Nil(t, fsys.Symlink("../outside", filepath.Join(dir, "out")))
+		require.Nil(t, fsys.Symlink(filepath.Join(root, "outside"), filepath.Join(dir, "absout")))
+		require.Nil(t, fsys.Symlink("loop", filepath.Join(dir, "loop")))
+
+		requireResolve := func(p, exp string) {
+			act, e := cookies.SafeResolveFS(fsys, dir, p)
+			require.Nil(t, e, "%s: %+v", p, e)
+			require.Equal(t, filepath.Join(dir, filepath.FromSlash(exp)), act, p)
+		}
+
+		requireResolve("in/abc.txt", "b/abc.txt")
+		requireResolve("abs/abc.txt", "b/abc.txt")
+		requireResolve("in/../b/new/x.txt", "b/new/x.txt")
+		requireResolve("new/../in", "b")
+
+		for _, p := range []string{"..", "out/x.txt", "absout", "self/..", "in/../.."} {
+			_, e := cookies.SafeResolveFS(fsys, dir, p)
+			require.True(t, errors.Is(e, cookies.ErrPathEscapes), "%s: %+v", p, e)
+		}
+
+		_, e := cookies.SafeResolveFS(fsys, dir, "loop/x.txt")
+		require.NotNil(t, e)
+	})
+}
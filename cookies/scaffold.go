@@ -0,0 +1,189 @@
+package cookies
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ScaffoldOptions configures Scaffold.
+type ScaffoldOptions struct {
+	Vars      interface{}      // Data passed to every template
+	Funcs     template.FuncMap // Additional template functions
+	Mode      os.FileMode      // Mode of created files, os.ModePerm if zero
+	Overwrite bool             // Replace existing files
+}
+
+// Scaffold renders the template tree 'tmpl', which uses the CreateFiles
+// format, into the directory 'dst' returning the relative paths of the files
+// and directories produced in lexical order.
+//
+// Both the paths and contents are text/template templates executed with
+// opts.Vars. A path that renders empty, or with an empty segment, is skipped
+// so files and directories may be made conditional:
+//
+//	"{{if .Docker}}Dockerfile{{end}}"
+//	"{{if .Docs}}docs{{end}}/index.md"
+//
+// Nothing is written if any template fails or, unless opts.Overwrite is set,
+// if any file already exists.
+func Scaffold(dst string, tmpl map[string][]byte, opts ScaffoldOptions) ([]string, error) {
+	return ScaffoldFS(OSFS, dst, tmpl, opts)
+}
+
+// ScaffoldFS is Scaffold for any FS.
+func ScaffoldFS(fsys FS, dst string, tmpl map[string][]byte, opts ScaffoldOptions) ([]string, error) {
+
+	if opts.Mode == 0 {
+		opts.Mode = os.ModePerm
+	}
+
+	files := make(map[string][]byte, len(tmpl))
+	for p, data := range tmpl {
+		rp, rdata, ok, e := renderScaffoldFile(p, data, opts)
+		if e != nil {
+			return nil, e
+		}
+		if !ok {
+			continue
+		}
+		if _, dup := files[rp]; dup {
+			return nil, fmt.Errorf("Multiple templates render to the same path: %s", rp)
+		}
+		files[rp] = rdata
+	}
+
+	produced := make([]string, 0, len(files))
+	for p := range files {
+		produced = append(produced, p)
+	}
+	sort.Strings(produced)
+
+	if !opts.Overwrite {
+		if e := checkScaffoldClobber(fsys, dst, produced); e != nil {
+			return nil, e
+		}
+	}
+
+	if e := CreateFilesFS(fsys, dst, opts.Mode, files); e != nil {
+		return nil, e
+	}
+	return produced, nil
+}
+
+// ScaffoldDir is Scaffold with the template tree read from the directory
+// 'src' using ReadFiles.
+func ScaffoldDir(dst, src string, opts ScaffoldOptions) ([]string, error) {
+	return ScaffoldDirFS(OSFS, dst, src, opts)
+}
+
+// ScaffoldDirFS is ScaffoldDir for any FS.
+func ScaffoldDirFS(fsys FS, dst, src string, opts ScaffoldOptions) ([]string, error) {
+	tmpl, e := ReadFilesFS(fsys, src, SnapshotOptions{})
+	if e != nil {
+		return nil, e
+	}
+	return ScaffoldFS(fsys, dst, tmpl, opts)
+}
+
+// ScaffoldTxtar is Scaffold with the template tree taken from the archive 'a'.
+func ScaffoldTxtar(dst string, a *Txtar, opts ScaffoldOptions) ([]string, error) {
+	return ScaffoldTxtarFS(OSFS, dst, a, opts)
+}
+
+// ScaffoldTxtarFS is ScaffoldTxtar for any FS.
+func ScaffoldTxtarFS(fsys FS, dst string, a *Txtar, opts ScaffoldOptions) ([]string, error) {
+	return ScaffoldFS(fsys, dst, a.Map(), opts)
+}
+
+// renderScaffoldFile renders the path 'p' and its 'data'. False is returned
+// if the file should be skipped.
+func renderScaffoldFile(p string, data []byte, opts ScaffoldOptions) (string, []byte, bool, error) {
+
+	isDir := strings.HasSuffix(p, "/")
+
+	rp, e := renderScaffold("path:"+p, []byte(p), opts)
+	if e != nil {
+		return "", nil, false, e
+	}
+
+	if !isScaffoldPath(rp, isDir) {
+		return "", nil, false, nil
+	}
+
+	clean := path.Clean(rp)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", nil, false, fmt.Errorf("Template path escapes destination: %s -> %s", p, rp)
+	}
+
+	if isDir {
+		return clean + "/", nil, true, nil
+	}
+
+	rdata, e := renderScaffold(p, data, opts)
+	if e != nil {
+		return "", nil, false, e
+	}
+	return clean, []byte(rdata), true, nil
+}
+
+// isScaffoldPath returns false if the rendered path 'p' is empty or contains
+// an empty segment. The trailing '/' of directories is ignored.
+func isScaffoldPath(p string, isDir bool) bool {
+	if isDir {
+		p = strings.TrimSuffix(p, "/")
+	}
+	if p == "" {
+		return false
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if strings.TrimSpace(seg) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func renderScaffold(name string, text []byte, opts ScaffoldOptions) (string, error) {
+	t := template.New(name).Option("missingkey=error")
+	if opts.Funcs != nil {
+		t = t.Funcs(opts.Funcs)
+	}
+
+	t, e := t.Parse(string(text))
+	if e != nil {
+		return "", Wrap(e, "Bad template %q", name)
+	}
+
+	buf := bytes.Buffer{}
+	if e := t.Execute(&buf, opts.Vars); e != nil {
+		return "", Wrap(e, "Failed to render template %q", name)
+	}
+	return buf.String(), nil
+}
+
+func checkScaffoldClobber(fsys FS, dst string, files []string) error {
+	var existing []string
+	for _, p := range files {
+		if strings.HasSuffix(p, "/") {
+			continue
+		}
+		ok, e := FileExistsFS(fsys, filepath.Join(dst, p))
+		if e != nil {
+			return e
+		}
+		if ok {
+			existing = append(existing, p)
+		}
+	}
+
+	if len(existing) > 0 {
+		return fmt.Errorf("Files already exist in %s: %s", dst, strings.Join(existing, ", "))
+	}
+	return nil
+}
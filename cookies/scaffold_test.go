@@ -0,0 +1,129 @@
+package cookies_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+type testScaffoldVars struct {
+	Name   string
+	Docker bool
+	Docs   bool
+}
+
+var testScaffold = map[string][]byte{
+	"{{.Name}}/main.go":                         []byte("package main // {{.Name | upper}}"),
+	"{{.Name}}/{{if .Docker}}Dockerfile{{end}}": []byte("FROM {{.Name}}"),
+	"{{if .Docs}}docs{{end}}/index.md":          []byte("# {{.Name}}"),
+	"{{.Name}}/empty/":                          nil,
+}
+
+var testScaffoldFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+}
+
+func TestScaffold(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	opts := cookies.ScaffoldOptions{
+		Vars:  testScaffoldVars{Name: "lancre", Docker: true},
+		Funcs: testScaffoldFuncs,
+	}
+
+	act, e := cookies.Scaffold(temp, testScaffold, opts)
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []string{
+		"lancre/Dockerfile",
+		"lancre/empty/",
+		"lancre/main.go",
+	}, act)
+
+	cookiestest.RequireFile(t, temp+"/lancre/main.go", "package main // LANCRE")
+	cookiestest.RequireFile(t, temp+"/lancre/Dockerfile", "FROM lancre")
+	require.DirExists(t, temp+"/lancre/empty")
+	cookiestest.RequireNotExists(t, temp+"/docs")
+
+	_, e = cookies.Scaffold(temp, testScaffold, opts)
+	require.NotNil(t, e)
+
+	opts.Overwrite = true
+	opts.Vars = testScaffoldVars{Name: "lancre", Docs: true}
+	act, e = cookies.Scaffold(temp, testScaffold, opts)
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []string{
+		"docs/index.md",
+		"lancre/empty/",
+		"lancre/main.go",
+	}, act)
+	cookiestest.RequireFile(t, temp+"/docs/index.md", "# lancre")
+}
+
+func TestScaffold_Errors(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	requireErr := func(tmpl map[string][]byte) {
+		_, e := cookies.Scaffold(temp, tmpl, cookies.ScaffoldOptions{Vars: testScaffoldVars{Name: ".."}})
+		require.NotNil(t, e)
+	}
+
+	requireErr(map[string][]byte{"{{.Missing}}.txt": nil})
+	requireErr(map[string][]byte{"abc.txt": []byte("{{.Name")})
+	requireErr(map[string][]byte{"{{.Name}}/abc.txt": nil})
+	requireErr(map[string][]byte{"abc.txt": nil, "{{print `abc.txt`}}": nil})
+}
+
+func TestScaffoldTxtar_AND_ScaffoldDir(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	a := cookies.ParseTxtar([]byte(`-- {{.Name}}.txt --
+Hello {{.Name}}
+`))
+	opts := cookies.ScaffoldOptions{Vars: testScaffoldVars{Name: "esme"}}
+
+	src := filepath.Join(temp, "src")
+	_, e := cookies.ScaffoldTxtar(src, a, opts)
+	require.Nil(t, e, "%+v", e)
+	cookiestest.RequireFile(t, src+"/esme.txt", "Hello esme\n")
+
+	dst := filepath.Join(temp, "dst")
+	opts.Vars = testScaffoldVars{Name: "gytha"}
+	act, e := cookies.ScaffoldDir(dst, src, opts)
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []string{"esme.txt"}, act)
+	cookiestest.RequireFile(t, dst+"/esme.txt", "Hello esme\n")
+}
+
+func TestScaffoldFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		opts := cookies.ScaffoldOptions{
+			Vars:  testScaffoldVars{Name: "lancre", Docs: true},
+			Funcs: testScaffoldFuncs,
+		}
+
+		src := filepath.Join(root, "src")
+		_, e := cookies.ScaffoldFS(fsys, src, testScaffold, opts)
+		require.Nil(t, e, "%+v", e)
+		cookiestest.RequireFileFS(t, fsys, src+"/lancre/main.go", "package main // LANCRE")
+		cookiestest.RequireFileFS(t, fsys, src+"/docs/index.md", "# lancre")
+
+		_, e = cookies.ScaffoldFS(fsys, src, testScaffold, opts)
+		require.NotNil(t, e)
+
+		a := cookies.ParseTxtar([]byte("-- {{.Name}}.txt --\nHello {{.Name}}\n"))
+		_, e = cookies.ScaffoldTxtarFS(fsys, root+"/txtar", a, opts)
+		require.Nil(t, e, "%+v", e)
+		cookiestest.RequireFileFS(t, fsys, root+"/txtar/lancre.txt", "Hello lancre\n")
+
+		act, e := cookies.ScaffoldDirFS(fsys, root+"/dst", root+"/txtar", opts)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []string{"lancre.txt"}, act)
+		cookiestest.RequireFileFS(t, fsys, root+"/dst/lancre.txt", "Hello lancre\n")
+	})
+}
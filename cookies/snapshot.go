@@ -0,0 +1,259 @@
+package cookies
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SnapshotOptions configures ReadFiles and CompareFiles. Patterns are matched
+// using path.Match against the '/' separated path relative to the root; a
+// pattern without a '/' is matched against the file name instead.
+type SnapshotOptions struct {
+	Include []string // Files must match one of these, all files if empty
+	Exclude []string // Files and directories matching one of these are ignored
+	MaxSize int64    // Maximum bytes read in total, unlimited if zero
+}
+
+// SnapshotDiff lists the paths that differ between a snapshot and a directory
+// or second snapshot.
+type SnapshotDiff struct {
+	Added   []string // Paths in the directory but not the snapshot
+	Removed []string // Paths in the snapshot but not the directory
+	Changed []string // Paths in both but with different content
+}
+
+// Empty returns true if there are no differences.
+func (d SnapshotDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// ReadFiles is the inverse of CreateFiles, it reads the directory tree 'root'
+// into a map of relative file paths to their content. Empty directories are
+// included with a '/' suffix and nil data. Symbolic links are followed, so
+// linked files and directories are read as if they were regular, while all
+// other non-regular files are ignored. A symbolic link loop is an error.
+func ReadFiles(root string, opts SnapshotOptions) (map[string][]byte, error) {
+	return ReadFilesFS(OSFS, root, opts)
+}
+
+// ReadFilesFS is ReadFiles for any FS.
+func ReadFilesFS(fsys FS, root string, opts SnapshotOptions) (map[string][]byte, error) {
+
+	if e := checkPatterns(opts.Include, opts.Exclude); e != nil {
+		return nil, e
+	}
+
+	real, e := evalSymlinksFS(fsys, root)
+	if e != nil {
+		return nil, e
+	}
+
+	r := snapshotReader{fs: fsys, opts: opts, files: map[string][]byte{}}
+	if e := r.readDir(root, "", []string{real}); e != nil {
+		return nil, e
+	}
+	return r.files, nil
+}
+
+type snapshotReader struct {
+	fs    FS
+	opts  SnapshotOptions
+	files map[string][]byte
+	total int64
+}
+
+// readDir reads the contents of the directory 'd', found at 'rel' relative to
+// the root, in lexical order. 'chain' holds the real paths of 'd' and its
+// ancestors so symbolic link loops can be detected.
+func (r *snapshotReader) readDir(d, rel string, chain []string) error {
+
+	entries, e := r.fs.ReadDir(d)
+	if e != nil {
+		return e
+	}
+
+	for _, info := range entries {
+		f := filepath.Join(d, info.Name())
+		if e := r.readEntry(f, path.Join(rel, info.Name()), info, chain); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func (r *snapshotReader) readEntry(f, rel string, info os.FileInfo, chain []string) error {
+
+	if matchAny(r.opts.Exclude, rel) {
+		return nil
+	}
+
+	isLink := info.Mode()&os.ModeSymlink != 0
+	if isLink {
+		var e error
+		if info, e = r.fs.Stat(f); e != nil {
+			return e
+		}
+	}
+
+	if info.IsDir() {
+		if isLink {
+			real, e := evalSymlinksFS(r.fs, f)
+			if e != nil {
+				return e
+			}
+			for _, p := range chain {
+				if p == real {
+					return fmt.Errorf("Symbolic link loop: %s -> %s", f, real)
+				}
+			}
+			chain = append(chain[:len(chain):len(chain)], real)
+		}
+
+		if e := r.readEmptyDir(f, rel); e != nil {
+			return e
+		}
+		return r.readDir(f, rel, chain)
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	if len(r.opts.Include) > 0 && !matchAny(r.opts.Include, rel) {
+		return nil
+	}
+
+	r.total += info.Size()
+	if r.opts.MaxSize > 0 && r.total > r.opts.MaxSize {
+		return fmt.Errorf("Exceeded maximum size of %d bytes: %s", r.opts.MaxSize, f)
+	}
+
+	data, e := ReadFileFS(r.fs, f)
+	if e != nil {
+		return e
+	}
+
+	r.files[rel] = data
+	return nil
+}
+
+// readEmptyDir records the directory 'd' if it has no entries once the
+// excluded ones are ignored.
+func (r *snapshotReader) readEmptyDir(d, rel string) error {
+	if len(r.opts.Include) > 0 && !matchAny(r.opts.Include, rel) {
+		return nil
+	}
+
+	entries, e := r.fs.ReadDir(d)
+	if e != nil {
+		return e
+	}
+
+	for _, info := range entries {
+		if !matchAny(r.opts.Exclude, path.Join(rel, info.Name())) {
+			return nil
+		}
+	}
+
+	r.files[rel+"/"] = nil
+	return nil
+}
+
+// CompareFiles reads the directory 'root', as ReadFiles does, and compares it
+// against the snapshot 'snap'.
+func CompareFiles(snap map[string][]byte, root string, opts SnapshotOptions) (SnapshotDiff, error) {
+	return CompareFilesFS(OSFS, snap, root, opts)
+}
+
+// CompareFilesFS is CompareFiles for any FS.
+func CompareFilesFS(fsys FS, snap map[string][]byte, root string, opts SnapshotOptions) (SnapshotDiff, error) {
+	files, e := ReadFilesFS(fsys, root, opts)
+	if e != nil {
+		return SnapshotDiff{}, e
+	}
+	return CompareSnapshots(snap, files), nil
+}
+
+// CompareSnapshots compares the snapshot 'old' against 'new'. Directory
+// entries, those suffixed with '/', that are implied by other paths are
+// ignored so maps written for CreateFiles may be compared against those
+// returned by ReadFiles.
+func CompareSnapshots(old, new map[string][]byte) SnapshotDiff {
+
+	old, new = trimImpliedDirs(old), trimImpliedDirs(new)
+	d := SnapshotDiff{}
+
+	for p, data := range new {
+		prev, ok := old[p]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, p)
+		case !bytes.Equal(prev, data):
+			d.Changed = append(d.Changed, p)
+		}
+	}
+
+	for p := range old {
+		if _, ok := new[p]; !ok {
+			d.Removed = append(d.Removed, p)
+		}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+	return d
+}
+
+// trimImpliedDirs returns a copy of 'files' without the directory entries
+// that are parents of other entries.
+func trimImpliedDirs(files map[string][]byte) map[string][]byte {
+
+	parents := map[string]bool{}
+	for p := range files {
+		for d := path.Dir(strings.TrimSuffix(p, "/")); d != "."; d = path.Dir(d) {
+			parents[d+"/"] = true
+		}
+	}
+
+	r := make(map[string][]byte, len(files))
+	for p, data := range files {
+		if strings.HasSuffix(p, "/") && parents[p] {
+			continue
+		}
+		if strings.HasSuffix(p, "/") {
+			data = nil
+		}
+		r[p] = data
+	}
+	return r
+}
+
+func checkPatterns(patternSets ...[]string) error {
+	for _, patterns := range patternSets {
+		for _, p := range patterns {
+			if _, e := path.Match(p, ""); e != nil {
+				return Wrap(e, "Bad pattern %q", p)
+			}
+		}
+	}
+	return nil
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		name := rel
+		if !strings.Contains(p, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
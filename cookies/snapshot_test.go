@@ -0,0 +1,137 @@
+package cookies_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFiles(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	exp := map[string][]byte{
+		"abc.txt":        []byte("Weatherwax"),
+		"xyz.md":         []byte("Ogg"),
+		"nested/abc.txt": []byte("Garlick"),
+		"empty/":         nil,
+	}
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, exp))
+
+	act, e := cookies.ReadFiles(temp, cookies.SnapshotOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, exp, act)
+
+	act, e = cookies.ReadFiles(temp, cookies.SnapshotOptions{
+		Include: []string{"*.txt"},
+		Exclude: []string{"nested"},
+	})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, map[string][]byte{
+		"abc.txt": []byte("Weatherwax"),
+	}, act)
+
+	act, e = cookies.ReadFiles(temp, cookies.SnapshotOptions{
+		Exclude: []string{"nested/*.txt", "xyz.md"},
+	})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, map[string][]byte{
+		"abc.txt": []byte("Weatherwax"),
+		"nested/": nil,
+		"empty/":  nil,
+	}, act)
+
+	_, e = cookies.ReadFiles(temp, cookies.SnapshotOptions{MaxSize: 12})
+	require.NotNil(t, e)
+
+	_, e = cookies.ReadFiles(temp, cookies.SnapshotOptions{Include: []string{"["}})
+	require.NotNil(t, e)
+}
+
+func TestReadFiles_Symlinks(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"a/abc.txt":    []byte("Weatherwax"),
+			"a/nested/xyz": []byte("Ogg"),
+			"a/empty/":     nil,
+		}))
+		require.Nil(t, fsys.Symlink("a", filepath.Join(root, "link")))
+		require.Nil(t, fsys.Symlink("abc.txt", filepath.Join(root, "a", "file.txt")))
+
+		act, e := cookies.ReadFilesFS(fsys, root, cookies.SnapshotOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, map[string][]byte{
+			"a/abc.txt":       []byte("Weatherwax"),
+			"a/file.txt":      []byte("Weatherwax"),
+			"a/nested/xyz":    []byte("Ogg"),
+			"a/empty/":        nil,
+			"link/abc.txt":    []byte("Weatherwax"),
+			"link/file.txt":   []byte("Weatherwax"),
+			"link/nested/xyz": []byte("Ogg"),
+			"link/empty/":     nil,
+		}, act)
+
+		require.Nil(t, fsys.Symlink("..", filepath.Join(root, "a", "loop")))
+		_, e = cookies.ReadFilesFS(fsys, root, cookies.SnapshotOptions{})
+		require.NotNil(t, e)
+	})
+}
+
+func TestCompareFiles(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		"abc.txt":        []byte("Weatherwax"),
+		"nested/abc.txt": []byte("Garlick"),
+		"new.txt":        []byte("Nanny"),
+	}))
+
+	d, e := cookies.CompareFiles(map[string][]byte{
+		"abc.txt":        []byte("Weatherwax"),
+		"nested/":        nil,
+		"nested/abc.txt": []byte("Ogg"),
+		"old.txt":        []byte("Magrat"),
+	}, temp, cookies.SnapshotOptions{})
+
+	require.Nil(t, e, "%+v", e)
+	require.False(t, d.Empty())
+	require.Equal(t, []string{"new.txt"}, d.Added)
+	require.Equal(t, []string{"old.txt"}, d.Removed)
+	require.Equal(t, []string{"nested/abc.txt"}, d.Changed)
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	d := cookies.CompareSnapshots(map[string][]byte{
+		"abc.txt": []byte("Weatherwax"),
+		"empty/":  []byte("ignored"),
+	}, map[string][]byte{
+		"abc.txt": []byte("Weatherwax"),
+		"empty/":  nil,
+	})
+	require.True(t, d.Empty())
+}
+
+func TestReadFilesFS_AND_CompareFilesFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		exp := map[string][]byte{
+			"abc.txt":        []byte("Weatherwax"),
+			"nested/abc.txt": []byte("Garlick"),
+			"empty/":         nil,
+		}
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, exp))
+
+		act, e := cookies.ReadFilesFS(fsys, root, cookies.SnapshotOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, exp, act)
+
+		require.Nil(t, cookies.WriteFileFS(fsys, root+"/abc.txt", []byte("Ogg"), 0666))
+		d, e := cookies.CompareFilesFS(fsys, exp, root, cookies.SnapshotOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []string{"abc.txt"}, d.Changed)
+	})
+}
@@ -43,3 +43,257 @@ func IndentLines(n int, v string, s string) string {
 
 	return sb.String()
 }
+
+// WrapOptions configures WrapText.
+type WrapOptions struct {
+	Width      int    // Maximum display columns per line, including the indent
+	BreakWords bool   // Break words wider than a line, else they overflow
+	Reflow     bool   // Join the lines of each paragraph before wrapping
+	Prefix     string // Repeatable prefix, as used with IndentLines, kept as indent
+	TabWidth   int    // Display columns of a tab within an indent, 4 if zero
+}
+
+// WrapText wraps the lines of 's' at word boundaries so none are wider than
+// opts.Width display columns. The indent of each line, its leading spaces,
+// tabs, and repetitions of opts.Prefix, is repeated on every line it wraps
+// onto. Blank lines, those holding only an indent, are kept as is so
+// paragraphs are preserved. With opts.Reflow, consecutive non-blank lines
+// sharing the same indent form a paragraph and are joined before wrapping.
+// Runs of white space between words are collapsed into single spaces. Returns
+// 's' unchanged if opts.Width is not positive.
+func WrapText(s string, opts WrapOptions) string {
+	if opts.Width <= 0 {
+		return s
+	}
+
+	if opts.TabWidth <= 0 {
+		opts.TabWidth = 4
+	}
+
+	lines := strings.Split(s, "\n")
+	var out []string
+
+	for i := 0; i < len(lines); i++ {
+		indent := lineIndent(lines[i], opts.Prefix)
+		text := lines[i][len(indent):]
+
+		if strings.TrimSpace(text) == "" {
+			out = append(out, lines[i])
+			continue
+		}
+
+		words := strings.Fields(text)
+		for opts.Reflow && i+1 < len(lines) {
+			next := lines[i+1]
+			if lineIndent(next, opts.Prefix) != indent || strings.TrimSpace(next[len(indent):]) == "" {
+				break
+			}
+			words = append(words, strings.Fields(next[len(indent):])...)
+			i++
+		}
+
+		out = append(out, wrapWords(indent, words, opts)...)
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// lineIndent returns the leading spaces, tabs, and repetitions of 'prefix'
+// of 'line'.
+func lineIndent(line, prefix string) string {
+	i := 0
+	for i < len(line) {
+		switch {
+		case prefix != "" && strings.HasPrefix(line[i:], prefix):
+			i += len(prefix)
+		case line[i] == ' ' || line[i] == '\t':
+			i++
+		default:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func wrapWords(indent string, words []string, opts WrapOptions) []string {
+
+	avail := opts.Width - indentWidth(indent, opts.TabWidth)
+	if avail < 1 {
+		avail = 1
+	}
+
+	var lines []string
+	cur, curWidth := strings.Builder{}, 0
+
+	flush := func() {
+		lines = append(lines, indent+cur.String())
+		cur.Reset()
+		curWidth = 0
+	}
+
+	for _, w := range words {
+		width := DisplayWidth(w)
+
+		if curWidth > 0 {
+			if curWidth+1+width <= avail {
+				cur.WriteByte(' ')
+				cur.WriteString(w)
+				curWidth += 1 + width
+				continue
+			}
+			flush()
+		}
+
+		if opts.BreakWords {
+			for width > avail {
+				head, tail := splitWidth(w, avail)
+				lines = append(lines, indent+head)
+				w, width = tail, DisplayWidth(tail)
+			}
+		}
+
+		cur.WriteString(w)
+		curWidth = width
+	}
+
+	if curWidth > 0 {
+		flush()
+	}
+	return lines
+}
+
+func indentWidth(indent string, tabWidth int) int {
+	n := 0
+	for _, ru := range indent {
+		if ru == '\t' {
+			n += tabWidth
+		} else {
+			n += runeWidth(ru)
+		}
+	}
+	return n
+}
+
+// splitWidth splits 's' after as many runes as fit within 'width' display
+// columns. At least one rune is always taken so progress is made.
+func splitWidth(s string, width int) (head, tail string) {
+	n := 0
+	prevZWJ := false
+
+	for i, ru := range s {
+		w := runeWidth(ru)
+		if prevZWJ {
+			w = 0
+		}
+		prevZWJ = ru == '\u200D'
+
+		if n+w > width && i > 0 {
+			return s[:i], s[i:]
+		}
+		n += w
+	}
+	return s, ""
+}
+
+// DisplayWidth returns the number of columns 's' occupies on a terminal
+// using a monospaced font. East Asian wide characters and emoji take two
+// columns while combining marks, format characters, and the parts of an emoji
+// joined by a zero width joiner take none.
+func DisplayWidth(s string) int {
+	n := 0
+	prevZWJ := false
+
+	for _, ru := range s {
+		if !prevZWJ {
+			n += runeWidth(ru)
+		}
+		prevZWJ = ru == '\u200D'
+	}
+	return n
+}
+
+func runeWidth(ru rune) int {
+	switch {
+	case ru == 0:
+		return 0
+	case ru >= 0x1F3FB && ru <= 0x1F3FF: // Skin tone modifiers
+		return 0
+	case unicode.In(ru, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wideRunes, ru):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// wideRunes are the East Asian wide and full width ranges along with the
+// emoji presented as wide by default.
+var wideRunes = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115F, 1},
+		{0x231A, 0x231B, 1},
+		{0x2329, 0x232A, 1},
+		{0x23E9, 0x23EC, 1},
+		{0x23F0, 0x23F0, 1},
+		{0x23F3, 0x23F3, 1},
+		{0x25FD, 0x25FE, 1},
+		{0x2614, 0x2615, 1},
+		{0x2648, 0x2653, 1},
+		{0x267F, 0x267F, 1},
+		{0x2693, 0x2693, 1},
+		{0x26A1, 0x26A1, 1},
+		{0x26AA, 0x26AB, 1},
+		{0x26BD, 0x26BE, 1},
+		{0x26C4, 0x26C5, 1},
+		{0x26CE, 0x26CE, 1},
+		{0x26D4, 0x26D4, 1},
+		{0x26EA, 0x26EA, 1},
+		{0x26F2, 0x26F3, 1},
+		{0x26F5, 0x26F5, 1},
+		{0x26FA, 0x26FA, 1},
+		{0x26FD, 0x26FD, 1},
+		{0x2705, 0x2705, 1},
+		{0x270A, 0x270B, 1},
+		{0x2728, 0x2728, 1},
+		{0x274C, 0x274C, 1},
+		{0x274E, 0x274E, 1},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2795, 0x2797, 1},
+		{0x27B0, 0x27B0, 1},
+		{0x27BF, 0x27BF, 1},
+		{0x2B1B, 0x2B1C, 1},
+		{0x2B50, 0x2B50, 1},
+		{0x2B55, 0x2B55, 1},
+		{0x2E80, 0x303E, 1},
+		{0x3041, 0x33FF, 1},
+		{0x3400, 0x4DBF, 1},
+		{0x4E00, 0x9FFF, 1},
+		{0xA000, 0xA4CF, 1},
+		{0xA960, 0xA97F, 1},
+		{0xAC00, 0xD7A3, 1},
+		{0xF900, 0xFAFF, 1},
+		{0xFE10, 0xFE19, 1},
+		{0xFE30, 0xFE6F, 1},
+		{0xFF00, 0xFF60, 1},
+		{0xFFE0, 0xFFE6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x16FE0, 0x16FE4, 1},
+		{0x17000, 0x18AFF, 1},
+		{0x1B000, 0x1B2FF, 1},
+		{0x1F004, 0x1F004, 1},
+		{0x1F0CF, 0x1F0CF, 1},
+		{0x1F18E, 0x1F18E, 1},
+		{0x1F191, 0x1F19A, 1},
+		{0x1F200, 0x1F251, 1},
+		{0x1F300, 0x1F64F, 1},
+		{0x1F680, 0x1F6FF, 1},
+		{0x1F7E0, 0x1F7EB, 1},
+		{0x1F90C, 0x1F9FF, 1},
+		{0x1FA70, 0x1FAFF, 1},
+		{0x20000, 0x2FFFD, 1},
+		{0x30000, 0x3FFFD, 1},
+	},
+}
@@ -1,29 +1,69 @@
-package cookies
+package cookies_test
 
 import (
 	"testing"
 
+	"github.com/PaulioRandall/go-cookies/cookies"
 	"github.com/stretchr/testify/require"
 )
 
 func TestStripSpace(t *testing.T) {
-	require.Equal(t, "Rincewind", StripSpace("Rince \n\t\f\r wind"))
-	require.Equal(t, "Rincewind", StripSpace("\t \n\t \r\n\n\fRincewind"))
-	require.Equal(t, "Rincewind", StripSpace("Rincewind\r\n \t\t\f \r  \v\v"))
-	require.Equal(t, "Rincewind", StripSpace("\r\nRi \tn\tc\t\t ew\f \r in\vd\v"))
-	require.Equal(t, "Rincewind", StripSpace("Rincewind"))
-	require.Equal(t, "", StripSpace(""))
-	require.Equal(t, "", StripSpace("\r\n \t\t \t\t \f \r  \v\v  "))
+	require.Equal(t, "Rincewind", cookies.StripSpace("Rince \n\t\f\r wind"))
+	require.Equal(t, "Rincewind", cookies.StripSpace("\t \n\t \r\n\n\fRincewind"))
+	require.Equal(t, "Rincewind", cookies.StripSpace("Rincewind\r\n \t\t\f \r  \v\v"))
+	require.Equal(t, "Rincewind", cookies.StripSpace("\r\nRi \tn\tc\t\t ew\f \r in\vd\v"))
+	require.Equal(t, "Rincewind", cookies.StripSpace("Rincewind"))
+	require.Equal(t, "", cookies.StripSpace(""))
+	require.Equal(t, "", cookies.StripSpace("\r\n \t\t \t\t \f \r  \v\v  "))
 }
 
 func TestIndentLines(t *testing.T) {
 	require.Equal(t,
 		"\t\t\n\t\tMoonglow\n\t\tMoonglow\n\t\t",
-		IndentLines(2, "\t", "\nMoonglow\nMoonglow\n"))
-	require.Equal(t, "Moonglow", IndentLines(1, "", "Moonglow"))
-	require.Equal(t, "Moonglow", IndentLines(0, "\t", "Moonglow"))
-	require.Equal(t, "\t", IndentLines(1, "\t", ""))
+		cookies.IndentLines(2, "\t", "\nMoonglow\nMoonglow\n"))
+	require.Equal(t, "Moonglow", cookies.IndentLines(1, "", "Moonglow"))
+	require.Equal(t, "Moonglow", cookies.IndentLines(0, "\t", "Moonglow"))
+	require.Equal(t, "\t", cookies.IndentLines(1, "\t", ""))
 	require.Panics(t, func() {
-		IndentLines(-5, "\t", "Moonglow")
+		cookies.IndentLines(-5, "\t", "Moonglow")
 	})
 }
+
+func TestDisplayWidth(t *testing.T) {
+	require.Equal(t, 9, cookies.DisplayWidth("Rincewind"))
+	require.Equal(t, 8, cookies.DisplayWidth("魔法使い"))
+	require.Equal(t, 4, cookies.DisplayWidth("🧙🔥"))
+	require.Equal(t, 2, cookies.DisplayWidth("👍🏽"))
+	require.Equal(t, 2, cookies.DisplayWidth("👨‍👩‍👧"))
+	require.Equal(t, 4, cookies.DisplayWidth("café"))
+	require.Equal(t, 0, cookies.DisplayWidth(""))
+}
+
+func TestWrapText(t *testing.T) {
+	in := "The Luggage follows its owner anywhere.\n\n  It has hundreds of little legs."
+	exp := "The Luggage\nfollows its\nowner\nanywhere.\n\n  It has\n  hundreds\n  of little\n  legs."
+	require.Equal(t, exp, cookies.WrapText(in, cookies.WrapOptions{Width: 12}))
+
+	in = "The Luggage\nfollows its owner.\n\nAnywhere."
+	exp = "The Luggage follows\nits owner.\n\nAnywhere."
+	require.Equal(t, exp, cookies.WrapText(in, cookies.WrapOptions{Width: 20, Reflow: true}))
+	require.Equal(t, "The Luggage\nfollows its owner.\n\nAnywhere.", cookies.WrapText(in, cookies.WrapOptions{Width: 20}))
+
+	in = cookies.IndentLines(2, "> ", "Sapient\npearwood never forgets.")
+	exp = "> > Sapient pearwood\n> > never forgets."
+	require.Equal(t, exp, cookies.WrapText(in, cookies.WrapOptions{Width: 20, Reflow: true, Prefix: "> "}))
+
+	in = "\tOok ook"
+	require.Equal(t, "\tOok\n\took", cookies.WrapText(in, cookies.WrapOptions{Width: 10, TabWidth: 8}))
+
+	in = "See Unseen-University-Library now"
+	require.Equal(t, "See\nUnseen-University-Library\nnow", cookies.WrapText(in, cookies.WrapOptions{Width: 10}))
+	require.Equal(t, "See\nUnseen-Uni\nversity-Li\nbrary now", cookies.WrapText(in, cookies.WrapOptions{Width: 10, BreakWords: true}))
+
+	require.Equal(t, "魔法使い\nの荷物", cookies.WrapText("魔法使い の荷物", cookies.WrapOptions{Width: 9}))
+	require.Equal(t, "魔法\n使い", cookies.WrapText("魔法使い", cookies.WrapOptions{Width: 5, BreakWords: true}))
+	require.Equal(t, "🧙🔥\n🧙", cookies.WrapText("🧙🔥 🧙", cookies.WrapOptions{Width: 5}))
+
+	require.Equal(t, "Moonglow\n", cookies.WrapText("Moonglow\n", cookies.WrapOptions{Width: 3}))
+	require.Equal(t, "Moonglow", cookies.WrapText("Moonglow", cookies.WrapOptions{}))
+}
@@ -0,0 +1,380 @@
+package cookies
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SyncCompare specifies how SyncDir decides whether a file has changed.
+type SyncCompare int
+
+const (
+	SyncSizeModTime SyncCompare = iota // Files differ if their sizes or modification times do
+	SyncHash                           // Files differ if their sizes or hashes do
+)
+
+// SyncAction is an operation SyncDir performs on a destination path.
+type SyncAction int
+
+const (
+	SyncMkdir  SyncAction = iota // Create a missing directory
+	SyncCreate                   // Copy a missing file or symbolic link
+	SyncUpdate                   // Replace a changed file or symbolic link
+	SyncChmod                    // Apply the source mode to an unchanged file
+	SyncDelete                   // Remove a path not within the source
+)
+
+// String returns the name of the action.
+func (a SyncAction) String() string {
+	switch a {
+	case SyncMkdir:
+		return "mkdir"
+	case SyncCreate:
+		return "create"
+	case SyncUpdate:
+		return "update"
+	case SyncChmod:
+		return "chmod"
+	case SyncDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// SyncOp is a single operation planned or performed by SyncDir.
+type SyncOp struct {
+	Action SyncAction
+	Path   string // Relative to the directory roots separated by '/'
+}
+
+// String returns the operation as "action path".
+func (op SyncOp) String() string {
+	return op.Action.String() + " " + op.Path
+}
+
+// SyncOptions configures SyncDir.
+type SyncOptions struct {
+	Compare SyncCompare // How changed files are detected
+	Algo    HashAlgo    // Used when Compare is SyncHash
+	Delete  bool        // Remove destination files not within the source
+	DryRun  bool        // Plan the operations without performing them
+	Walk    WalkOptions // Selects the files to sync in both trees
+	Copy    CopyOptions // Progress and throttling of file copies, which are always atomic
+}
+
+// SyncDir makes the directory 'dst' mirror 'src', creating 'dst' if it
+// doesn't exist, by copying only the files that are new or have changed. The
+// source mode and modification time are applied to each file copied and the
+// mode to each directory. Symbolic links are recreated unless
+// opts.Walk.FollowSymlinks is set. A destination path that is a different
+// kind of file to its source, e.g. a file where the source has a directory,
+// is removed and replaced.
+//
+// Only files selected by opts.Walk are considered in either tree so excluded
+// destination files are never updated or deleted, unless within an
+// extraneous directory which is removed as a whole.
+//
+// The operations are returned in the order they were performed, or would be
+// if opts.DryRun is set. If an operation fails then the operations completed
+// so far are returned along with the error. Files are copied atomically, see
+// CopyOptions, so a failed sync never leaves a partial file behind.
+func SyncDir(src, dst string, opts SyncOptions) ([]SyncOp, error) {
+	return SyncDirContextFS(context.Background(), OSFS, src, dst, opts)
+}
+
+// SyncDirFS is SyncDir for any FS.
+func SyncDirFS(fsys FS, src, dst string, opts SyncOptions) ([]SyncOp, error) {
+	return SyncDirContextFS(context.Background(), fsys, src, dst, opts)
+}
+
+// SyncDirContext is SyncDir but stops when 'ctx' is cancelled, returning the
+// operations completed so far and the context's error. opts.Copy.Progress is
+// given the bytes copied out of the total size of all files to be copied.
+func SyncDirContext(ctx context.Context, src, dst string, opts SyncOptions) ([]SyncOp, error) {
+	return SyncDirContextFS(ctx, OSFS, src, dst, opts)
+}
+
+// SyncDirContextFS is SyncDirContext for any FS.
+func SyncDirContextFS(ctx context.Context, fsys FS, src, dst string, opts SyncOptions) ([]SyncOp, error) {
+
+	srcInfo, e := fsys.Stat(src)
+	if e != nil || !srcInfo.IsDir() {
+		return nil, fmt.Errorf("Missing or not a directory: %s", src)
+	}
+
+	if in, e := isWithin(src, dst); e != nil || in {
+		return nil, fmt.Errorf("Destination is within source: %s in %s", dst, src)
+	}
+
+	s := syncer{fs: fsys, src: src, dst: dst, opts: opts}
+	plan, e := s.plan()
+	if e != nil || opts.DryRun {
+		return plan, e
+	}
+
+	return s.apply(ctx, srcInfo, plan)
+}
+
+type syncer struct {
+	fs       FS
+	src, dst string
+	opts     SyncOptions
+	srcFiles map[string]WalkEntry
+	dstFiles map[string]WalkEntry
+}
+
+// syncEntries walks 'root', if it exists, returning its files and directories
+// by relative path along with the paths in walk order.
+func (s *syncer) syncEntries(root string, follow bool) (map[string]WalkEntry, []string, error) {
+
+	files := map[string]WalkEntry{}
+	var order []string
+
+	if ok, e := IsDirFS(s.fs, root); e != nil || !ok {
+		return files, nil, e
+	}
+
+	opts := s.opts.Walk
+	opts.Dirs, opts.FollowSymlinks = true, follow
+
+	e := WalkFS(s.fs, root, opts, func(entry WalkEntry) error {
+		files[entry.Rel] = entry
+		order = append(order, entry.Rel)
+		return nil
+	})
+
+	return files, order, e
+}
+
+// plan compares the two trees returning the operations needed to sync them.
+func (s *syncer) plan() ([]SyncOp, error) {
+
+	var srcOrder, dstOrder []string
+	var e error
+
+	if s.srcFiles, srcOrder, e = s.syncEntries(s.src, s.opts.Walk.FollowSymlinks); e != nil {
+		return nil, e
+	}
+	if s.dstFiles, dstOrder, e = s.syncEntries(s.dst, false); e != nil {
+		return nil, e
+	}
+
+	var plan []SyncOp
+	var removed []string // Destination directories that will be removed
+
+	for _, rel := range srcOrder {
+		ops, e := s.planEntry(rel)
+		if e != nil {
+			return nil, e
+		}
+		if len(ops) > 1 && s.dstFiles[rel].Info.IsDir() {
+			removed = append(removed, rel)
+		}
+		plan = append(plan, ops...)
+	}
+
+	for _, rel := range dstOrder {
+		if _, ok := s.srcFiles[rel]; ok || !s.opts.Delete || isSyncRemoved(removed, rel) {
+			continue
+		}
+		plan = append(plan, SyncOp{SyncDelete, rel})
+		if s.dstFiles[rel].Info.IsDir() {
+			removed = append(removed, rel)
+		}
+	}
+
+	return plan, nil
+}
+
+func isSyncRemoved(removed []string, rel string) bool {
+	for _, dir := range removed {
+		if strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// planEntry returns the operations needed to sync the source entry 'rel'.
+func (s *syncer) planEntry(rel string) ([]SyncOp, error) {
+
+	sm := s.srcFiles[rel].Info.Mode()
+	d, exists := s.dstFiles[rel]
+
+	create := SyncCreate
+	if sm.IsDir() {
+		create = SyncMkdir
+	}
+
+	switch {
+	case !sm.IsDir() && !sm.IsRegular() && sm&os.ModeSymlink == 0:
+		return nil, nil
+
+	case !exists:
+		return []SyncOp{{create, rel}}, nil
+
+	case sm&os.ModeType != d.Info.Mode()&os.ModeType:
+		return []SyncOp{{SyncDelete, rel}, {create, rel}}, nil
+	}
+
+	if sm&os.ModeSymlink == 0 && !sm.IsDir() {
+		same, e := s.sameFile(s.srcFiles[rel], d)
+		if e != nil {
+			return nil, e
+		}
+		if !same {
+			return []SyncOp{{SyncUpdate, rel}}, nil
+		}
+	}
+
+	if sm&os.ModeSymlink != 0 {
+		same, e := s.sameSymlink(s.srcFiles[rel].Path, d.Path)
+		if e != nil {
+			return nil, e
+		}
+		if !same {
+			return []SyncOp{{SyncUpdate, rel}}, nil
+		}
+		return nil, nil
+	}
+
+	if sm.Perm() != d.Info.Mode().Perm() {
+		return []SyncOp{{SyncChmod, rel}}, nil
+	}
+	return nil, nil
+}
+
+// sameFile returns true if the regular files 'a' and 'b' are the same
+// according to the comparison option.
+func (s *syncer) sameFile(a, b WalkEntry) (bool, error) {
+
+	if a.Info.Size() != b.Info.Size() {
+		return false, nil
+	}
+
+	if s.opts.Compare != SyncHash {
+		return a.Info.ModTime().Equal(b.Info.ModTime()), nil
+	}
+
+	ah, e := HashFileFS(s.fs, a.Path, s.opts.Algo)
+	if e != nil {
+		return false, e
+	}
+	bh, e := HashFileFS(s.fs, b.Path, s.opts.Algo)
+	if e != nil {
+		return false, e
+	}
+	return ah == bh, nil
+}
+
+func (s *syncer) sameSymlink(a, b string) (bool, error) {
+	at, e := s.fs.Readlink(a)
+	if e != nil {
+		return false, e
+	}
+	bt, e := s.fs.Readlink(b)
+	if e != nil {
+		return false, e
+	}
+	return at == bt, nil
+}
+
+// apply performs the operations within 'plan'. Directory modes are applied
+// last, deepest first, so read only directories may still be populated.
+func (s *syncer) apply(ctx context.Context, srcInfo os.FileInfo, plan []SyncOp) ([]SyncOp, error) {
+
+	if e := s.fs.MkdirAll(s.dst, srcInfo.Mode().Perm()|0700); e != nil {
+		return nil, e
+	}
+
+	var total int64
+	for _, op := range plan {
+		if op.Action == SyncCreate || op.Action == SyncUpdate {
+			if info := s.srcFiles[op.Path].Info; info.Mode().IsRegular() {
+				total += info.Size()
+			}
+		}
+	}
+
+	copyOpts := s.opts.Copy
+	copyOpts.Atomic = true
+	cp := newCopier(copyOpts, total)
+
+	var done []SyncOp
+	dirs := []string{""}
+
+	for _, op := range plan {
+		if e := ctx.Err(); e != nil {
+			return done, e
+		}
+		if e := s.applyOp(ctx, cp, op); e != nil {
+			return done, Wrap(e, "Failed to %s", op)
+		}
+		done = append(done, op)
+
+		if op.Action == SyncMkdir || op.Action == SyncChmod {
+			dirs = append(dirs, op.Path)
+		}
+	}
+
+	cp.finish()
+
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, rel := range dirs {
+		info := srcInfo
+		if rel != "" {
+			info = s.srcFiles[rel].Info
+		}
+		if !info.IsDir() {
+			continue
+		}
+		dst := filepath.Join(s.dst, filepath.FromSlash(rel))
+		if e := s.fs.Chmod(dst, info.Mode().Perm()); e != nil {
+			return done, e
+		}
+	}
+
+	return done, nil
+}
+
+func (s *syncer) applyOp(ctx context.Context, cp *copier, op SyncOp) error {
+
+	dst := filepath.Join(s.dst, filepath.FromSlash(op.Path))
+	if op.Action == SyncDelete {
+		return s.fs.RemoveAll(dst)
+	}
+
+	entry := s.srcFiles[op.Path]
+	mode := entry.Info.Mode()
+
+	switch {
+	case op.Action == SyncChmod:
+		if mode.IsDir() {
+			return nil // Applied once the sync is complete
+		}
+		return s.fs.Chmod(dst, mode.Perm())
+
+	case mode.IsDir():
+		return s.fs.MkdirAll(dst, mode.Perm()|0700)
+
+	case mode&os.ModeSymlink != 0:
+		target, e := s.fs.Readlink(entry.Path)
+		if e != nil {
+			return e
+		}
+		if e := s.fs.Remove(dst); e != nil && !os.IsNotExist(e) {
+			return e
+		}
+		return s.fs.Symlink(target, dst)
+	}
+
+	if e := cp.copyFile(ctx, s.fs, entry.Path, dst); e != nil {
+		return e
+	}
+	return preserveAttrs(s.fs, dst, entry.Info)
+}
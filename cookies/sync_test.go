@@ -0,0 +1,218 @@
+package cookies_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncDir(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	src, dst := filepath.Join(temp, "src"), filepath.Join(temp, "dst")
+	require.Nil(t, cookies.CreateFiles(src, os.ModePerm, map[string][]byte{
+		"abc.txt":        []byte("Weatherwax"),
+		"nested/xyz.txt": []byte("Ogg"),
+		"empty/":         nil,
+	}))
+	require.Nil(t, os.Chmod(src+"/abc.txt", 0600))
+
+	ops, e := cookies.SyncDir(src, dst, cookies.SyncOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []cookies.SyncOp{
+		{cookies.SyncCreate, "abc.txt"},
+		{cookies.SyncMkdir, "empty"},
+		{cookies.SyncMkdir, "nested"},
+		{cookies.SyncCreate, "nested/xyz.txt"},
+	}, ops)
+
+	cookiestest.RequireFile(t, dst+"/abc.txt", "Weatherwax")
+	cookiestest.RequireFile(t, dst+"/nested/xyz.txt", "Ogg")
+	require.DirExists(t, dst+"/empty")
+
+	stat, e := os.Stat(dst + "/abc.txt")
+	require.Nil(t, e)
+	require.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+	ops, e = cookies.SyncDir(src, dst, cookies.SyncOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Empty(t, ops)
+
+	mtime := time.Date(2019, 4, 15, 21, 50, 33, 0, time.UTC)
+	require.Nil(t, ioutil.WriteFile(src+"/nested/xyz.txt", []byte("Nanny"), 0666))
+	require.Nil(t, os.Chtimes(src+"/nested/xyz.txt", mtime, mtime))
+	require.Nil(t, os.Chmod(src+"/abc.txt", 0640))
+	require.Nil(t, cookies.CreateFiles(dst, os.ModePerm, map[string][]byte{
+		"extra.txt":     []byte("Garlick"),
+		"old/stale.txt": []byte("Tiffany"),
+	}))
+
+	exp := []cookies.SyncOp{
+		{cookies.SyncChmod, "abc.txt"},
+		{cookies.SyncUpdate, "nested/xyz.txt"},
+		{cookies.SyncDelete, "extra.txt"},
+		{cookies.SyncDelete, "old"},
+	}
+
+	ops, e = cookies.SyncDir(src, dst, cookies.SyncOptions{Delete: true, DryRun: true})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, exp, ops)
+	cookiestest.RequireFile(t, dst+"/nested/xyz.txt", "Ogg")
+	cookiestest.RequireFile(t, dst+"/extra.txt", "Garlick")
+
+	ops, e = cookies.SyncDir(src, dst, cookies.SyncOptions{Delete: true})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, exp, ops)
+	cookiestest.RequireFile(t, dst+"/nested/xyz.txt", "Nanny")
+	cookiestest.RequireNotExists(t, dst+"/extra.txt")
+	cookiestest.RequireNotExists(t, dst+"/old")
+
+	stat, e = os.Stat(dst + "/nested/xyz.txt")
+	require.Nil(t, e)
+	require.True(t, mtime.Equal(stat.ModTime()))
+
+	stat, e = os.Stat(dst + "/abc.txt")
+	require.Nil(t, e)
+	require.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+}
+
+func TestSyncDir_Hash(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	src, dst := filepath.Join(temp, "src"), filepath.Join(temp, "dst")
+	require.Nil(t, cookies.CreateFiles(src, os.ModePerm, map[string][]byte{
+		"abc.txt": []byte("Weatherwax"),
+	}))
+	require.Nil(t, cookies.CreateFiles(dst, os.ModePerm, map[string][]byte{
+		"abc.txt": []byte("Weatherwix"),
+	}))
+
+	mtime := time.Date(2019, 4, 15, 21, 50, 33, 0, time.UTC)
+	require.Nil(t, os.Chtimes(src+"/abc.txt", mtime, mtime))
+	require.Nil(t, os.Chtimes(dst+"/abc.txt", mtime, mtime))
+
+	ops, e := cookies.SyncDir(src, dst, cookies.SyncOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Empty(t, ops)
+
+	ops, e = cookies.SyncDir(src, dst, cookies.SyncOptions{Compare: cookies.SyncHash})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []cookies.SyncOp{{cookies.SyncUpdate, "abc.txt"}}, ops)
+	cookiestest.RequireFile(t, dst+"/abc.txt", "Weatherwax")
+}
+
+func TestSyncDir_KindChanged(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	src, dst := filepath.Join(temp, "src"), filepath.Join(temp, "dst")
+	require.Nil(t, cookies.CreateFiles(src, os.ModePerm, map[string][]byte{
+		"abc":     []byte("Weatherwax"),
+		"xyz/123": []byte("Ogg"),
+	}))
+	require.Nil(t, cookies.CreateFiles(dst, os.ModePerm, map[string][]byte{
+		"abc/nested.txt": []byte("Garlick"),
+		"xyz":            []byte("Nanny"),
+	}))
+	require.Nil(t, os.Symlink("abc", src+"/link"))
+
+	ops, e := cookies.SyncDir(src, dst, cookies.SyncOptions{Delete: true})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []cookies.SyncOp{
+		{cookies.SyncDelete, "abc"},
+		{cookies.SyncCreate, "abc"},
+		{cookies.SyncCreate, "link"},
+		{cookies.SyncDelete, "xyz"},
+		{cookies.SyncMkdir, "xyz"},
+		{cookies.SyncCreate, "xyz/123"},
+	}, ops)
+
+	cookiestest.RequireFile(t, dst+"/abc", "Weatherwax")
+	cookiestest.RequireFile(t, dst+"/xyz/123", "Ogg")
+	target, e := os.Readlink(dst + "/link")
+	require.Nil(t, e)
+	require.Equal(t, "abc", target)
+
+	_, e = cookies.SyncDir(src, src+"/xyz", cookies.SyncOptions{})
+	require.NotNil(t, e)
+}
+
+func TestSyncDirFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+		require.Nil(t, cookies.CreateFilesFS(fsys, src, os.ModePerm, map[string][]byte{
+			"abc.txt":        []byte("Weatherwax"),
+			"nested/xyz.txt": []byte("Ogg"),
+		}))
+		require.Nil(t, fsys.Symlink("abc.txt", filepath.Join(src, "link.txt")))
+		require.Nil(t, cookies.CreateFilesFS(fsys, dst, os.ModePerm, map[string][]byte{
+			"extra.txt": []byte("Garlick"),
+		}))
+
+		opts := cookies.SyncOptions{Compare: cookies.SyncHash, Algo: cookies.SHA1, Delete: true}
+		ops, e := cookies.SyncDirFS(fsys, src, dst, opts)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []cookies.SyncOp{
+			{cookies.SyncCreate, "abc.txt"},
+			{cookies.SyncCreate, "link.txt"},
+			{cookies.SyncMkdir, "nested"},
+			{cookies.SyncCreate, "nested/xyz.txt"},
+			{cookies.SyncDelete, "extra.txt"},
+		}, ops)
+
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(dst, "abc.txt"), "Weatherwax")
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(dst, "nested", "xyz.txt"), "Ogg")
+		target, e := fsys.Readlink(filepath.Join(dst, "link.txt"))
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, "abc.txt", target)
+
+		ops, e = cookies.SyncDirFS(fsys, src, dst, opts)
+		require.Nil(t, e, "%+v", e)
+		require.Empty(t, ops)
+	})
+}
+
+func TestSyncDirContext(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+		require.Nil(t, cookies.CreateFilesFS(fsys, src, os.ModePerm, map[string][]byte{
+			"abc.txt":        []byte("Weatherwax"),
+			"nested/xyz.txt": []byte("Ogg"),
+		}))
+		require.Nil(t, cookies.CreateFilesFS(fsys, dst, os.ModePerm, map[string][]byte{
+			"nested/xyz.txt": []byte("Nanny"),
+		}))
+
+		var calls [][2]int64
+		opts := cookies.SyncOptions{Copy: cookies.CopyOptions{
+			Progress: func(copied, total int64) {
+				calls = append(calls, [2]int64{copied, total})
+			},
+		}}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ops, e := cookies.SyncDirContextFS(ctx, fsys, src, dst, opts)
+		require.True(t, errors.Is(e, context.Canceled), "%+v", e)
+		require.Empty(t, ops)
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(dst, "nested", "xyz.txt"), "Nanny")
+
+		ops, e = cookies.SyncDirContextFS(context.Background(), fsys, src, dst, opts)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []cookies.SyncOp{
+			{cookies.SyncCreate, "abc.txt"},
+			{cookies.SyncUpdate, "nested/xyz.txt"},
+		}, ops)
+		require.Equal(t, [][2]int64{{13, 13}}, calls)
+		cookiestest.RequireFileFS(t, fsys, filepath.Join(dst, "nested", "xyz.txt"), "Ogg")
+	})
+}
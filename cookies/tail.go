@@ -0,0 +1,284 @@
+package cookies
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// TailOptions configures Tail and TailChan.
+type TailOptions struct {
+	Lines    int           // Existing lines to emit first, none if zero, all if negative
+	Interval time.Duration // Time between polls, 250ms if zero
+}
+
+// Tail follows the file 'f', as 'tail -F' does, calling 'fn' with each line
+// appended to it without the line ending. A final line is only emitted once
+// its line ending is written. If the file is truncated it's read again from
+// the start and if it's replaced, e.g. renamed away by log rotation, the rest
+// of the old file is read before the new one is followed from its start. The
+// file need not exist yet.
+//
+// Tail blocks until 'ctx' is cancelled, returning nil, or 'fn' or reading the
+// file fails, returning the error.
+func Tail(ctx context.Context, f string, opts TailOptions, fn func(line string) error) error {
+	return TailFS(ctx, OSFS, f, opts, fn)
+}
+
+// TailFS is Tail for any FS.
+func TailFS(ctx context.Context, fsys FS, f string, opts TailOptions, fn func(line string) error) error {
+
+	if opts.Interval <= 0 {
+		opts.Interval = 250 * time.Millisecond
+	}
+
+	t := &tailer{fs: fsys, path: f, fn: fn}
+	defer t.close()
+
+	if e := t.open(true, opts.Lines); e != nil {
+		return e
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if e := t.poll(); e != nil {
+			return e
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// TailChan is Tail with the lines delivered over a channel. Both channels are
+// closed once tailing stops, the error channel first receiving the error if
+// tailing failed.
+func TailChan(ctx context.Context, f string, opts TailOptions) (<-chan string, <-chan error) {
+	return TailChanFS(ctx, OSFS, f, opts)
+}
+
+// TailChanFS is TailChan for any FS.
+func TailChanFS(ctx context.Context, fsys FS, f string, opts TailOptions) (<-chan string, <-chan error) {
+	lines, errs := make(chan string), make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(lines)
+
+		e := TailFS(ctx, fsys, f, opts, func(line string) error {
+			select {
+			case <-ctx.Done():
+				return nil // Tail returns at the next cancellation check
+			case lines <- line:
+				return nil
+			}
+		})
+
+		if e != nil {
+			errs <- e
+		}
+	}()
+
+	return lines, errs
+}
+
+type tailer struct {
+	fs      FS
+	path    string
+	fn      func(string) error
+	file    File
+	info    os.FileInfo // Of the open file
+	offset  int64
+	partial []byte // Trailing bytes not yet ending with a new line
+}
+
+// open opens the file, if it exists, positioned at the start, or if 'initial'
+// is set, according to the number of existing 'lines' wanted.
+func (t *tailer) open(initial bool, lines int) error {
+	file, e := t.fs.Open(t.path)
+	if os.IsNotExist(e) {
+		return nil
+	}
+	if e != nil {
+		return e
+	}
+
+	info, e := file.Stat()
+	if e != nil {
+		file.Close()
+		return e
+	}
+
+	var offset int64
+	if initial && lines >= 0 {
+		if offset, e = lastLinesOffset(file, info.Size(), lines); e != nil {
+			file.Close()
+			return e
+		}
+	}
+
+	if _, e := file.Seek(offset, io.SeekStart); e != nil {
+		file.Close()
+		return e
+	}
+
+	t.file, t.info, t.offset, t.partial = file, info, offset, nil
+	return nil
+}
+
+func (t *tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+// poll emits any new lines then checks if the file was truncated or
+// replaced.
+func (t *tailer) poll() error {
+	if t.file == nil {
+		return t.open(false, 0)
+	}
+
+	if e := t.read(); e != nil {
+		return e
+	}
+
+	info, e := t.fs.Stat(t.path)
+	if os.IsNotExist(e) {
+		return nil // Moved away, wait for the replacement
+	}
+	if e != nil {
+		return e
+	}
+
+	if !sameFileInfo(t.info, info) {
+		if e := t.read(); e != nil {
+			return e
+		}
+		if e := t.flush(); e != nil {
+			return e
+		}
+		t.close()
+		return t.open(false, 0)
+	}
+
+	if info.Size() < t.offset {
+		t.offset, t.partial = 0, nil
+		_, e := t.file.Seek(0, io.SeekStart)
+		return e
+	}
+
+	return nil
+}
+
+// read emits the complete lines appended since the last read.
+func (t *tailer) read() error {
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, e := t.file.Read(buf)
+		t.offset += int64(n)
+		if n > 0 {
+			if e := t.emit(buf[:n]); e != nil {
+				return e
+			}
+		}
+
+		if e == io.EOF {
+			return nil
+		}
+		if e != nil {
+			return e
+		}
+	}
+}
+
+func (t *tailer) emit(data []byte) error {
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			t.partial = append(t.partial, data...)
+			return nil
+		}
+
+		line := append(t.partial, data[:i]...)
+		t.partial, data = nil, data[i+1:]
+
+		if e := t.fn(string(bytes.TrimSuffix(line, []byte("\r")))); e != nil {
+			return e
+		}
+	}
+}
+
+// flush emits the unterminated final line of a file that will not grow.
+func (t *tailer) flush() error {
+	if len(t.partial) == 0 {
+		return nil
+	}
+	line := t.partial
+	t.partial = nil
+	return t.fn(string(bytes.TrimSuffix(line, []byte("\r"))))
+}
+
+// lastLinesOffset returns the offset of the start of the last 'n' lines of
+// 'file' which is 'size' bytes long. A final line without a line ending
+// counts as a line.
+func lastLinesOffset(file File, size int64, n int) (int64, error) {
+	if n == 0 || size == 0 {
+		return size, nil
+	}
+
+	const chunk = 4096
+	buf := make([]byte, chunk)
+	end := size
+
+	// A final new line terminates the last line rather than starting another.
+	last := make([]byte, 1)
+	if e := readFileAt(file, last, size-1); e != nil {
+		return 0, e
+	}
+	if last[0] == '\n' {
+		end--
+	}
+
+	for pos := end; pos > 0; {
+		l := int64(chunk)
+		if pos < l {
+			l = pos
+		}
+		pos -= l
+
+		if e := readFileAt(file, buf[:l], pos); e != nil {
+			return 0, e
+		}
+
+		for i := l - 1; i >= 0; i-- {
+			if buf[i] != '\n' {
+				continue
+			}
+			if n--; n == 0 {
+				return pos + i + 1, nil
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// readFileAt fills 'buf' with the bytes of 'file' starting at 'offset'. The
+// file's position is left undefined.
+func readFileAt(file File, buf []byte, offset int64) error {
+	if _, e := file.Seek(offset, io.SeekStart); e != nil {
+		return e
+	}
+	_, e := io.ReadFull(file, buf)
+	return e
+}
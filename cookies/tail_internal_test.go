@@ -0,0 +1,29 @@
+package cookies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailer_Missing(t *testing.T) {
+	t.Parallel()
+
+	fsys := NewMemFS()
+	require.Nil(t, fsys.MkdirAll("/root", 0755))
+
+	// Polled directly so the file is certain not to exist when tailing starts
+	var act []string
+	tl := &tailer{fs: fsys, path: "/root/app.log", fn: func(line string) error {
+		act = append(act, line)
+		return nil
+	}}
+	defer tl.close()
+
+	require.Nil(t, tl.open(true, 0))
+	require.Nil(t, tl.poll())
+	require.Nil(t, WriteFileFS(fsys, "/root/app.log", []byte("Ogg\n"), 0666))
+	require.Nil(t, tl.poll())
+	require.Nil(t, tl.poll())
+	require.Equal(t, []string{"Ogg"}, act)
+}
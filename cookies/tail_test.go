@@ -0,0 +1,120 @@
+package cookies_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func appendTestFile(t *testing.T, f, s string) {
+	appendTestFileFS(t, cookies.OSFS, f, s)
+}
+
+func appendTestFileFS(t *testing.T, fsys cookies.FS, f, s string) {
+	file, e := fsys.OpenFile(f, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	require.Nil(t, e, "%+v", e)
+	_, e = file.Write([]byte(s))
+	require.Nil(t, e, "%+v", e)
+	require.Nil(t, file.Close())
+}
+
+func requireLines(t *testing.T, lines <-chan string, exps ...string) {
+	for _, exp := range exps {
+		select {
+		case act := <-lines:
+			require.Equal(t, exp, act)
+		case <-time.After(time.Second):
+			require.Fail(t, "Timed out waiting for line", exp)
+		}
+	}
+}
+
+func TestTail(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "app.log")
+	require.Nil(t, ioutil.WriteFile(f, []byte("Weatherwax\nOgg\nGarlick\n"), 0666))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lines, errs := cookies.TailChan(ctx, f, cookies.TailOptions{Lines: 2, Interval: 5 * time.Millisecond})
+	requireLines(t, lines, "Ogg", "Garlick")
+
+	appendTestFile(t, f, "Nanny\r\nTiff")
+	requireLines(t, lines, "Nanny")
+	appendTestFile(t, f, "any\n")
+	requireLines(t, lines, "Tiffany")
+
+	// Truncated
+	require.Nil(t, ioutil.WriteFile(f, []byte("Esme\n"), 0666))
+	requireLines(t, lines, "Esme")
+
+	// Rotated
+	appendTestFile(t, f, "Magrat")
+	require.Nil(t, os.Rename(f, f+".1"))
+	appendTestFile(t, f+".1", "\nAgnes")
+	appendTestFile(t, f, "Perdita\n")
+	requireLines(t, lines, "Magrat", "Agnes", "Perdita")
+
+	cancel()
+	for range lines {
+	}
+	require.Nil(t, <-errs)
+}
+
+func TestTail_Lines(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "app.log")
+	require.Nil(t, ioutil.WriteFile(f, []byte("a\nb\nc"), 0666))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lines, _ := cookies.TailChan(ctx, f, cookies.TailOptions{Lines: -1, Interval: 5 * time.Millisecond})
+	requireLines(t, lines, "a", "b")
+
+	lines, _ = cookies.TailChan(ctx, f, cookies.TailOptions{Lines: 5, Interval: 5 * time.Millisecond})
+	appendTestFile(t, f, "\n")
+	requireLines(t, lines, "a", "b", "c")
+
+	exp := errors.New("Octarine")
+	e := cookies.Tail(ctx, f, cookies.TailOptions{Lines: 1}, func(line string) error {
+		return exp
+	})
+	require.Equal(t, exp, e)
+}
+
+func TestTailFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		f := filepath.Join(root, "app.log")
+		require.Nil(t, fsys.MkdirAll(root, os.ModePerm))
+		require.Nil(t, cookies.WriteFileFS(fsys, f, []byte("Weatherwax\nOgg\n"), 0666))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		lines, errs := cookies.TailChanFS(ctx, fsys, f, cookies.TailOptions{Lines: 1, Interval: 5 * time.Millisecond})
+		requireLines(t, lines, "Ogg")
+
+		appendTestFileFS(t, fsys, f, "Nanny\n")
+		requireLines(t, lines, "Nanny")
+
+		// Rotated
+		appendTestFileFS(t, fsys, f, "Magrat")
+		require.Nil(t, fsys.Rename(f, f+".1"))
+		appendTestFileFS(t, fsys, f, "Perdita\n")
+		requireLines(t, lines, "Magrat", "Perdita")
+
+		cancel()
+		for range lines {
+		}
+		require.Nil(t, <-errs)
+	})
+}
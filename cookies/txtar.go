@@ -0,0 +1,203 @@
+package cookies
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Txtar is a txtar text archive. It starts with a comment followed by a
+// series of files each beginning with a '-- name --' marker line:
+//
+//	This is the comment.
+//	-- abc.txt --
+//	Weatherwax
+//	-- nested/xyz.txt --
+//	Ogg
+//	-- empty/ --
+//
+// As with CreateFiles, file names suffixed with a '/' are empty directories
+// and any data they hold is ignored. Because each file section ends at the
+// next marker line, file data always ends with a new line once formatted.
+type Txtar struct {
+	Comment []byte
+	Files   []TxtarFile
+}
+
+// TxtarFile is a single file within a Txtar.
+type TxtarFile struct {
+	Name string
+	Data []byte
+}
+
+var (
+	txtarMarker        = []byte("-- ")
+	txtarNewlineMarker = []byte("\n-- ")
+	txtarMarkerEnd     = []byte(" --")
+)
+
+// NewTxtar returns a new Txtar containing the comment 'comment' and the files
+// within 'files', which uses the CreateFiles format, sorted by name.
+func NewTxtar(comment []byte, files map[string][]byte) *Txtar {
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	a := &Txtar{Comment: comment}
+	for _, name := range names {
+		a.Files = append(a.Files, TxtarFile{Name: name, Data: files[name]})
+	}
+
+	return a
+}
+
+// ParseTxtar parses the txtar formatted 'data'. Parsing never fails, text
+// before the first marker line is the comment.
+func ParseTxtar(data []byte) *Txtar {
+	a := &Txtar{}
+
+	var name string
+	a.Comment, name, data = findTxtarMarker(data)
+
+	for name != "" {
+		f := TxtarFile{Name: name}
+		f.Data, name, data = findTxtarMarker(data)
+		a.Files = append(a.Files, f)
+	}
+
+	return a
+}
+
+// ReadTxtar reads and parses the txtar file 'f'.
+func ReadTxtar(f string) (*Txtar, error) {
+	return ReadTxtarFS(OSFS, f)
+}
+
+// ReadTxtarFS is ReadTxtar for any FS.
+func ReadTxtarFS(fsys FS, f string) (*Txtar, error) {
+	data, e := ReadFileFS(fsys, f)
+	if e != nil {
+		return nil, e
+	}
+	return ParseTxtar(data), nil
+}
+
+// WriteTxtar writes the archive 'a' to the file 'f' using WriteFileAtomic.
+func WriteTxtar(f string, a *Txtar) error {
+	return WriteTxtarFS(OSFS, f, a)
+}
+
+// WriteTxtarFS is WriteTxtar for any FS.
+func WriteTxtarFS(fsys FS, f string, a *Txtar) error {
+	return WriteFileAtomicFS(fsys, f, a.Format(), 0666)
+}
+
+// Format returns the archive in the txtar format.
+func (a *Txtar) Format() []byte {
+	buf := bytes.Buffer{}
+	buf.Write(fixTxtarNewline(a.Comment))
+
+	for _, f := range a.Files {
+		fmt.Fprintf(&buf, "-- %s --\n", f.Name)
+		if !strings.HasSuffix(f.Name, "/") {
+			buf.Write(fixTxtarNewline(f.Data))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// Map returns the archive files in the format used by CreateFiles. If a name
+// appears more than once the last file wins.
+func (a *Txtar) Map() map[string][]byte {
+	files := make(map[string][]byte, len(a.Files))
+	for _, f := range a.Files {
+		if strings.HasSuffix(f.Name, "/") {
+			files[f.Name] = nil
+			continue
+		}
+		files[f.Name] = f.Data
+	}
+	return files
+}
+
+// CreateFilesFromTxtar creates the files within the archive 'a' using
+// CreateFiles.
+func CreateFilesFromTxtar(root string, mode os.FileMode, a *Txtar) error {
+	return CreateFilesFromTxtarFS(OSFS, root, mode, a)
+}
+
+// CreateFilesFromTxtarFS is CreateFilesFromTxtar for any FS.
+func CreateFilesFromTxtarFS(fsys FS, root string, mode os.FileMode, a *Txtar) error {
+	return CreateFilesFS(fsys, root, mode, a.Map())
+}
+
+// DirToTxtar reads the directory 'root' into a new archive using ReadFiles.
+func DirToTxtar(root string, opts SnapshotOptions) (*Txtar, error) {
+	return DirToTxtarFS(OSFS, root, opts)
+}
+
+// DirToTxtarFS is DirToTxtar for any FS.
+func DirToTxtarFS(fsys FS, root string, opts SnapshotOptions) (*Txtar, error) {
+	files, e := ReadFilesFS(fsys, root, opts)
+	if e != nil {
+		return nil, e
+	}
+	return NewTxtar(nil, files), nil
+}
+
+// findTxtarMarker returns the data before the next marker line, the name
+// within the marker, and the data after it. If there are no more markers then
+// all of 'data' is returned with an empty name.
+func findTxtarMarker(data []byte) (before []byte, name string, after []byte) {
+	i := 0
+	for {
+		if name, after = isTxtarMarker(data[i:]); name != "" {
+			return data[:i], name, after
+		}
+
+		j := bytes.Index(data[i:], txtarNewlineMarker)
+		if j < 0 {
+			return fixTxtarNewline(data), "", nil
+		}
+		i += j + 1
+	}
+}
+
+// isTxtarMarker returns the name within the marker line at the start of
+// 'data' and the data following the line. An empty name is returned if 'data'
+// doesn't start with a marker line.
+func isTxtarMarker(data []byte) (name string, after []byte) {
+	if !bytes.HasPrefix(data, txtarMarker) {
+		return "", nil
+	}
+
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data, after = data[:i], data[i+1:]
+	}
+
+	if len(data) < len(txtarMarker)+len(txtarMarkerEnd) ||
+		!bytes.HasSuffix(data, txtarMarkerEnd) {
+		return "", nil
+	}
+
+	name = string(data[len(txtarMarker) : len(data)-len(txtarMarkerEnd)])
+	return strings.TrimSpace(name), after
+}
+
+// fixTxtarNewline returns 'data' with a trailing new line added if it is not
+// empty and doesn't already have one.
+func fixTxtarNewline(data []byte) []byte {
+	if len(data) == 0 || data[len(data)-1] == '\n' {
+		return data
+	}
+	r := make([]byte, len(data)+1)
+	copy(r, data)
+	r[len(data)] = '\n'
+	return r
+}
@@ -0,0 +1,99 @@
+package cookies_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+const testTxtar = `Witches of Lancre.
+-- abc.txt --
+Weatherwax
+-- nested/xyz.txt --
+Ogg
+-- empty/ --
+`
+
+func TestParseTxtar(t *testing.T) {
+	a := cookies.ParseTxtar([]byte(testTxtar))
+
+	require.Equal(t, "Witches of Lancre.\n", string(a.Comment))
+	require.Equal(t, []cookies.TxtarFile{
+		{Name: "abc.txt", Data: []byte("Weatherwax\n")},
+		{Name: "nested/xyz.txt", Data: []byte("Ogg\n")},
+		{Name: "empty/", Data: []byte{}},
+	}, a.Files)
+
+	require.Equal(t, map[string][]byte{
+		"abc.txt":        []byte("Weatherwax\n"),
+		"nested/xyz.txt": []byte("Ogg\n"),
+		"empty/":         nil,
+	}, a.Map())
+}
+
+func TestTxtar_Format(t *testing.T) {
+	a := cookies.NewTxtar([]byte("Witches of Lancre."), map[string][]byte{
+		"nested/xyz.txt": []byte("Ogg"),
+		"abc.txt":        []byte("Weatherwax\n"),
+		"empty/":         nil,
+	})
+
+	exp := `Witches of Lancre.
+-- abc.txt --
+Weatherwax
+-- empty/ --
+-- nested/xyz.txt --
+Ogg
+`
+	require.Equal(t, exp, string(a.Format()))
+	require.Equal(t, exp, string(cookies.ParseTxtar(a.Format()).Format()))
+}
+
+func TestCreateFilesFromTxtar_AND_DirToTxtar(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	f := filepath.Join(temp, "fixture.txtar")
+	require.Nil(t, cookies.WriteTxtar(f, cookies.ParseTxtar([]byte(testTxtar))))
+
+	a, e := cookies.ReadTxtar(f)
+	require.Nil(t, e, "%+v", e)
+
+	root := filepath.Join(temp, "root")
+	require.Nil(t, cookies.CreateFilesFromTxtar(root, os.ModePerm, a))
+	cookiestest.RequireFile(t, root+"/abc.txt", "Weatherwax\n")
+	cookiestest.RequireFile(t, root+"/nested/xyz.txt", "Ogg\n")
+	require.DirExists(t, root+"/empty")
+
+	a, e = cookies.DirToTxtar(root, cookies.SnapshotOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, `-- abc.txt --
+Weatherwax
+-- empty/ --
+-- nested/xyz.txt --
+Ogg
+`, string(a.Format()))
+}
+
+func TestTxtarFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		f := filepath.Join(root, "fixture.txtar")
+		require.Nil(t, fsys.MkdirAll(root, os.ModePerm))
+		require.Nil(t, cookies.WriteTxtarFS(fsys, f, cookies.ParseTxtar([]byte(testTxtar))))
+
+		a, e := cookies.ReadTxtarFS(fsys, f)
+		require.Nil(t, e, "%+v", e)
+
+		dir := filepath.Join(root, "dir")
+		require.Nil(t, cookies.CreateFilesFromTxtarFS(fsys, dir, os.ModePerm, a))
+		cookiestest.RequireFileFS(t, fsys, dir+"/nested/xyz.txt", "Ogg\n")
+
+		b, e := cookies.DirToTxtarFS(fsys, dir, cookies.SnapshotOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, a.Map(), b.Map())
+	})
+}
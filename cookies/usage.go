@@ -0,0 +1,186 @@
+package cookies
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// UsageOptions configures DiskUsage.
+type UsageOptions struct {
+	Depth int         // Deepest directories reported, all if zero
+	Top   int         // Only the largest directories reported, all if zero
+	Walk  WalkOptions // Selects the files counted
+}
+
+// DirUsage is the total size of the files within a directory and all of its
+// sub directories.
+type DirUsage struct {
+	Path  string `json:"path"` // Relative to the root separated by '/'
+	Depth int    `json:"depth"`
+	Size  int64  `json:"size"`
+	Files int    `json:"files"`
+}
+
+// DiskUsage returns the size of every directory, including the root itself as
+// ".", within the tree 'root' as 'du' does. Only regular files, selected by
+// opts.Walk, are counted. The result is sorted largest first.
+func DiskUsage(root string, opts UsageOptions) ([]DirUsage, error) {
+	return DiskUsageFS(OSFS, root, opts)
+}
+
+// DiskUsageFS is DiskUsage for any FS.
+func DiskUsageFS(fsys FS, root string, opts UsageOptions) ([]DirUsage, error) {
+
+	dirs := map[string]*DirUsage{".": {Path: "."}}
+	walk := opts.Walk
+	walk.Dirs = true
+
+	e := WalkFS(fsys, root, walk, func(entry WalkEntry) error {
+		if entry.Info.IsDir() {
+			if opts.Depth == 0 || entry.Depth <= opts.Depth {
+				dirs[entry.Rel] = &DirUsage{Path: entry.Rel, Depth: entry.Depth}
+			}
+			return nil
+		}
+
+		if !entry.Info.Mode().IsRegular() {
+			return nil
+		}
+
+		for d := path.Dir(entry.Rel); ; d = path.Dir(d) {
+			if u, ok := dirs[d]; ok {
+				u.Size += entry.Info.Size()
+				u.Files++
+			}
+			if d == "." {
+				return nil
+			}
+		}
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	usage := make([]DirUsage, 0, len(dirs))
+	for _, u := range dirs {
+		usage = append(usage, *u)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Size != usage[j].Size {
+			return usage[i].Size > usage[j].Size
+		}
+		return usage[i].Path < usage[j].Path
+	})
+
+	if opts.Top > 0 && len(usage) > opts.Top {
+		usage = usage[:opts.Top]
+	}
+	return usage, nil
+}
+
+// WriteUsageTable writes 'usage' to 'w' as an aligned table.
+func WriteUsageTable(w io.Writer, usage []DirUsage) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIZE\tFILES\tPATH")
+	for _, u := range usage {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", fmtBytes(u.Size), u.Files, u.Path)
+	}
+	return tw.Flush()
+}
+
+// DuplicateGroup is a set of files with identical content.
+type DuplicateGroup struct {
+	Size  int64    `json:"size"` // Of each file
+	Hash  string   `json:"hash"`
+	Paths []string `json:"paths"` // Relative to the root separated by '/'
+}
+
+// Wasted returns the bytes that would be freed by keeping only one file.
+func (g DuplicateGroup) Wasted() int64 {
+	return g.Size * int64(len(g.Paths)-1)
+}
+
+// FindDuplicates returns the groups of regular files, selected by 'opts',
+// within the tree 'root' that have identical content. Files are first grouped
+// by size so only those sharing a size are hashed. Empty files are ignored.
+// Groups are sorted by wasted space, largest first.
+func FindDuplicates(root string, algo HashAlgo, opts WalkOptions) ([]DuplicateGroup, error) {
+	return FindDuplicatesFS(OSFS, root, algo, opts)
+}
+
+// FindDuplicatesFS is FindDuplicates for any FS.
+func FindDuplicatesFS(fsys FS, root string, algo HashAlgo, opts WalkOptions) ([]DuplicateGroup, error) {
+
+	bySize := map[int64][]WalkEntry{}
+	e := WalkFS(fsys, root, opts, func(entry WalkEntry) error {
+		if size := entry.Info.Size(); size > 0 && entry.Info.Mode().IsRegular() {
+			bySize[size] = append(bySize[size], entry)
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	groups := []DuplicateGroup{}
+	for size, entries := range bySize {
+		if len(entries) < 2 {
+			continue
+		}
+
+		byHash := map[string][]string{}
+		for _, entry := range entries {
+			h, e := HashFileFS(fsys, entry.Path, algo)
+			if e != nil {
+				return nil, e
+			}
+			byHash[h] = append(byHash[h], entry.Rel)
+		}
+
+		for h, paths := range byHash {
+			if len(paths) > 1 {
+				sort.Strings(paths)
+				groups = append(groups, DuplicateGroup{Size: size, Hash: h, Paths: paths})
+			}
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if wi, wj := groups[i].Wasted(), groups[j].Wasted(); wi != wj {
+			return wi > wj
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+
+	return groups, nil
+}
+
+// WriteDuplicatesTable writes 'groups' to 'w' as an aligned table.
+func WriteDuplicatesTable(w io.Writer, groups []DuplicateGroup) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIZE\tWASTED\tPATHS")
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", fmtBytes(g.Size), fmtBytes(g.Wasted()), strings.Join(g.Paths, ", "))
+	}
+	return tw.Flush()
+}
+
+// fmtBytes returns 'n' bytes as a human readable size using binary units.
+func fmtBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
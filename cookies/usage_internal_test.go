@@ -0,0 +1,15 @@
+package cookies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFmtBytes(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "0 B", fmtBytes(0))
+	require.Equal(t, "1023 B", fmtBytes(1023))
+	require.Equal(t, "1.5 KiB", fmtBytes(1536))
+	require.Equal(t, "2.0 MiB", fmtBytes(2*1024*1024))
+}
@@ -0,0 +1,120 @@
+package cookies_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskUsage(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		"abc.txt":          []byte("Weatherwax"),
+		"a/xyz.txt":        []byte("Ogg"),
+		"a/b/c/nested.txt": []byte(strings.Repeat("x", 2048)),
+		"d/garlick.txt":    []byte("Garlick"),
+		"empty/":           nil,
+	}))
+
+	act, e := cookies.DiskUsage(temp, cookies.UsageOptions{Depth: 2})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []cookies.DirUsage{
+		{Path: ".", Depth: 0, Size: 2068, Files: 4},
+		{Path: "a", Depth: 1, Size: 2051, Files: 2},
+		{Path: "a/b", Depth: 2, Size: 2048, Files: 1},
+		{Path: "d", Depth: 1, Size: 7, Files: 1},
+		{Path: "empty", Depth: 1, Size: 0, Files: 0},
+	}, act)
+
+	act, e = cookies.DiskUsage(temp, cookies.UsageOptions{Top: 2, Walk: cookies.WalkOptions{Exclude: []string{"a/b"}}})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []cookies.DirUsage{
+		{Path: ".", Depth: 0, Size: 20, Files: 3},
+		{Path: "d", Depth: 1, Size: 7, Files: 1},
+	}, act)
+
+	buf := bytes.Buffer{}
+	require.Nil(t, cookies.WriteUsageTable(&buf, act))
+	require.Equal(t, "SIZE  FILES  PATH\n20 B  3      .\n7 B   1      d\n", buf.String())
+}
+
+func TestFindDuplicates(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		"abc.txt":       []byte("Weatherwax"),
+		"a/abc.txt":     []byte("Weatherwax"),
+		"b/abc.txt":     []byte("Weatherwax"),
+		"other.txt":     []byte("Weatherwix"),
+		"ogg.txt":       []byte("Ogg"),
+		"c/ogg.txt":     []byte("Ogg"),
+		"empty.txt":     nil,
+		"c/empty.txt":   nil,
+		"big/image.bin": []byte(strings.Repeat("x", 2048)),
+	}))
+
+	act, e := cookies.FindDuplicates(temp, cookies.SHA256, cookies.WalkOptions{})
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, 2, len(act))
+
+	require.Equal(t, int64(10), act[0].Size)
+	require.Equal(t, int64(20), act[0].Wasted())
+	require.Equal(t, []string{"a/abc.txt", "abc.txt", "b/abc.txt"}, act[0].Paths)
+	h, e := cookies.HashFile(temp+"/abc.txt", cookies.SHA256)
+	require.Nil(t, e)
+	require.Equal(t, h, act[0].Hash)
+
+	require.Equal(t, []string{"c/ogg.txt", "ogg.txt"}, act[1].Paths)
+
+	buf := bytes.Buffer{}
+	require.Nil(t, cookies.WriteDuplicatesTable(&buf, act))
+	require.Equal(t, `SIZE  WASTED  PATHS
+10 B  20 B    a/abc.txt, abc.txt, b/abc.txt
+3 B   3 B     c/ogg.txt, ogg.txt
+`, buf.String())
+}
+
+func TestDiskUsageFS_AND_FindDuplicatesFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			"abc.txt":   []byte("Weatherwax"),
+			"a/abc.txt": []byte("Weatherwax"),
+			"a/xyz.txt": []byte("Ogg"),
+		}))
+
+		usage, e := cookies.DiskUsageFS(fsys, root, cookies.UsageOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []cookies.DirUsage{
+			{Path: ".", Depth: 0, Size: 23, Files: 3},
+			{Path: "a", Depth: 1, Size: 13, Files: 2},
+		}, usage)
+
+		dupes, e := cookies.FindDuplicatesFS(fsys, root, cookies.SHA256, cookies.WalkOptions{})
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, 1, len(dupes))
+		require.Equal(t, []string{"a/abc.txt", "abc.txt"}, dupes[0].Paths)
+	})
+}
+
+func TestFindDuplicatesFS_Error(t *testing.T) {
+	t.Parallel()
+
+	m := cookies.NewMemFS()
+	require.Nil(t, cookies.CreateFilesFS(m, "/root", os.ModePerm, map[string][]byte{
+		"abc.txt":   []byte("Weatherwax"),
+		"a/abc.txt": []byte("Weatherwax"),
+	}))
+
+	exp := errors.New("Octarine")
+	m.Fail("read", "/root/abc.txt", exp)
+	_, e := cookies.FindDuplicatesFS(m, "/root", cookies.SHA256, cookies.WalkOptions{})
+	require.True(t, errors.Is(e, exp), "%+v", e)
+}
@@ -0,0 +1,412 @@
+package cookies
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WalkOptions configures Walk and Walker. Patterns are matched using
+// MatchGlob against the '/' separated path relative to the root.
+type WalkOptions struct {
+	Patterns       []string // Files must match one of these, all files if empty
+	Exclude        []string // Files and directories matching one of these are skipped
+	IgnoreFile     string   // Name of .gitignore style files to honour, none if empty
+	MaxDepth       int      // Maximum depth below the root, unlimited if zero
+	Dirs           bool     // Also visit directories, Patterns do not apply to them
+	FollowSymlinks bool     // Treat symbolic links as the files they point to, a loop is an error
+}
+
+// WalkEntry is a file or directory found during a walk.
+type WalkEntry struct {
+	Path  string      // The root joined with Rel
+	Rel   string      // Path relative to the root separated by '/'
+	Info  os.FileInfo // Info about the link target if the link was followed
+	Depth int         // One for entries directly within the root
+}
+
+// Walk walks the file tree 'root', in lexical order, calling 'f' for each
+// file, and directory if requested, selected by 'opts'. As with filepath.Walk,
+// if 'f' returns filepath.SkipDir for a directory then its contents are
+// skipped, and for a file the remaining files within its directory are
+// skipped. Any other error stops the walk and is returned.
+func Walk(root string, opts WalkOptions, f func(WalkEntry) error) error {
+	return WalkFS(OSFS, root, opts, f)
+}
+
+// WalkFS is Walk for any FS.
+func WalkFS(fsys FS, root string, opts WalkOptions, f func(WalkEntry) error) error {
+	w, e := NewWalkerFS(fsys, root, opts)
+	if e != nil {
+		return e
+	}
+
+	for w.Next() {
+		entry := w.Entry()
+		e := f(entry)
+		if e == filepath.SkipDir {
+			if entry.Info.IsDir() {
+				w.SkipDir()
+			} else {
+				w.skipSiblings()
+			}
+			continue
+		}
+		if e != nil {
+			return e
+		}
+	}
+
+	return w.Err()
+}
+
+// Walker is an iterator over the files within a file tree. Directories are
+// read only as the walk reaches them so large trees may be walked lazily:
+//
+//	w, e := NewWalker(root, opts)
+//	...
+//	for w.Next() {
+//		entry := w.Entry()
+//		...
+//	}
+//	if e := w.Err(); e != nil {
+//		...
+//	}
+type Walker struct {
+	fs      FS
+	opts    WalkOptions
+	stack   []*walkFrame
+	entry   WalkEntry
+	pending *walkFrame // Directory to descend into on the next call to Next
+	err     error
+}
+
+type walkFrame struct {
+	path    string
+	rel     string
+	depth   int
+	entries []os.FileInfo
+	next    int
+	ignores []ignoreRule
+	chain   []string // Real paths of the directory and its ancestors
+}
+
+// NewWalker returns a new Walker positioned before the first entry of the file
+// tree 'root'.
+func NewWalker(root string, opts WalkOptions) (*Walker, error) {
+	return NewWalkerFS(OSFS, root, opts)
+}
+
+// NewWalkerFS is NewWalker for any FS.
+func NewWalkerFS(fsys FS, root string, opts WalkOptions) (*Walker, error) {
+
+	for _, p := range append(opts.Patterns[:len(opts.Patterns):len(opts.Patterns)], opts.Exclude...) {
+		if e := checkGlob(p); e != nil {
+			return nil, e
+		}
+	}
+
+	info, e := fsys.Stat(root)
+	if e != nil {
+		return nil, e
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("Not a directory: %s", root)
+	}
+
+	real, e := evalSymlinksFS(fsys, root)
+	if e != nil {
+		return nil, e
+	}
+
+	w := &Walker{fs: fsys, opts: opts}
+	w.pending = &walkFrame{path: root, chain: []string{real}}
+	return w, nil
+}
+
+// Next advances the walker to the next entry returning false once there are
+// no more entries or an error occurred.
+func (w *Walker) Next() bool {
+
+	if w.err != nil {
+		return false
+	}
+
+	if w.pending != nil {
+		f := w.pending
+		w.pending = nil
+		if w.err = w.open(f); w.err != nil {
+			return false
+		}
+	}
+
+	for len(w.stack) > 0 {
+		top := w.stack[len(w.stack)-1]
+		if top.next >= len(top.entries) {
+			w.stack = w.stack[:len(w.stack)-1]
+			continue
+		}
+
+		info := top.entries[top.next]
+		top.next++
+
+		if w.visit(top, info) {
+			return true
+		}
+		if w.err != nil {
+			return false
+		}
+	}
+
+	return false
+}
+
+// Entry returns the current entry.
+func (w *Walker) Entry() WalkEntry {
+	return w.entry
+}
+
+// SkipDir prevents the walker descending into the current entry if it's a
+// directory.
+func (w *Walker) SkipDir() {
+	w.pending = nil
+}
+
+// skipSiblings prevents the walker visiting the remaining entries within the
+// directory of the current entry.
+func (w *Walker) skipSiblings() {
+	w.pending = nil
+	if n := len(w.stack); n > 0 {
+		w.stack = w.stack[:n-1]
+	}
+}
+
+// Err returns the first error encountered during the walk.
+func (w *Walker) Err() error {
+	return w.err
+}
+
+// visit evaluates the file 'info' within the directory 'parent' returning
+// true if it should be the next entry.
+func (w *Walker) visit(parent *walkFrame, info os.FileInfo) bool {
+
+	name := info.Name()
+	entry := WalkEntry{
+		Path:  filepath.Join(parent.path, name),
+		Rel:   path.Join(parent.rel, name),
+		Info:  info,
+		Depth: parent.depth + 1,
+	}
+	chain := parent.chain
+
+	if info.Mode()&os.ModeSymlink != 0 && w.opts.FollowSymlinks {
+		target, real, e := followWalkLink(w.fs, entry.Path, chain)
+		if e != nil {
+			if !w.excluded(parent, entry.Rel, true) {
+				w.err = e
+			}
+			return false
+		}
+		if target != nil {
+			entry.Info = target
+			if target.IsDir() {
+				chain = append(chain[:len(chain):len(chain)], real)
+			}
+		}
+	}
+
+	isDir := entry.Info.IsDir()
+	if w.excluded(parent, entry.Rel, isDir) {
+		return false
+	}
+
+	if isDir {
+		if w.opts.MaxDepth == 0 || entry.Depth < w.opts.MaxDepth {
+			w.pending = &walkFrame{
+				path:    entry.Path,
+				rel:     entry.Rel,
+				depth:   entry.Depth,
+				ignores: parent.ignores,
+				chain:   chain,
+			}
+		}
+
+		if !w.opts.Dirs {
+			if f := w.pending; f != nil {
+				w.pending = nil
+				w.err = w.open(f)
+			}
+			return false
+		}
+
+		w.entry = entry
+		return true
+	}
+
+	if len(w.opts.Patterns) > 0 && !matchAnyGlob(w.opts.Patterns, entry.Rel) {
+		return false
+	}
+
+	w.entry = entry
+	return true
+}
+
+// followWalkLink returns the info and real path of the symbolic link 'f'
+// target. A nil info is returned if the link is broken and an error if it
+// leads back to a directory within 'chain'.
+func followWalkLink(fsys FS, f string, chain []string) (os.FileInfo, string, error) {
+	info, e := fsys.Stat(f)
+	if e != nil {
+		return nil, "", nil
+	}
+
+	real, e := evalSymlinksFS(fsys, f)
+	if e != nil {
+		return nil, "", nil
+	}
+
+	for _, p := range chain {
+		if p == real {
+			return nil, "", fmt.Errorf("Symbolic link loop: %s -> %s", f, real)
+		}
+	}
+
+	return info, real, nil
+}
+
+// open reads the directory within 'f' and pushes it onto the stack.
+func (w *Walker) open(f *walkFrame) error {
+	var e error
+	if f.entries, e = w.fs.ReadDir(f.path); e != nil {
+		return e
+	}
+
+	if w.opts.IgnoreFile != "" {
+		rules, e := readIgnoreFile(w.fs, filepath.Join(f.path, w.opts.IgnoreFile), f.rel)
+		if e != nil {
+			return e
+		}
+		if len(rules) > 0 {
+			f.ignores = append(f.ignores[:len(f.ignores):len(f.ignores)], rules...)
+		}
+	}
+
+	w.stack = append(w.stack, f)
+	return nil
+}
+
+func (w *Walker) excluded(parent *walkFrame, rel string, isDir bool) bool {
+	if matchAnyGlob(w.opts.Exclude, rel) {
+		return true
+	}
+
+	ignored := false
+	for _, r := range parent.ignores {
+		if r.match(rel, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if ok, _ := MatchGlob(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRule is a single pattern from a .gitignore style file.
+type ignoreRule struct {
+	base     string // Directory of the ignore file relative to the walk root
+	pattern  string
+	negate   bool // Pattern started with '!'
+	dirOnly  bool // Pattern ended with '/'
+	anchored bool // Pattern is relative to 'base' rather than any directory
+}
+
+// readIgnoreFile parses the .gitignore style file 'f', found within the
+// directory 'base', if it exists.
+func readIgnoreFile(fsys FS, f, base string) ([]ignoreRule, error) {
+	data, e := ReadFileFS(fsys, f)
+	if os.IsNotExist(e) {
+		return nil, nil
+	}
+	if e != nil {
+		return nil, e
+	}
+
+	var rules []ignoreRule
+	sc := bufio.NewScanner(bytes.NewReader(data))
+
+	for sc.Scan() {
+		if r, ok := parseIgnoreRule(sc.Text(), base); ok {
+			rules = append(rules, r)
+		}
+	}
+
+	return rules, sc.Err()
+}
+
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+
+	if line == "" || line[0] == '#' {
+		return ignoreRule{}, false
+	}
+
+	r := ignoreRule{base: base}
+
+	if line[0] == '!' {
+		r.negate = true
+		line = line[1:]
+	} else if line[0] == '\\' {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if line == "" || checkGlob(line) != nil {
+		return ignoreRule{}, false
+	}
+
+	r.pattern = line
+	return r, true
+}
+
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+
+	if !r.anchored {
+		rel = path.Base(rel)
+	}
+
+	ok, _ := MatchGlob(r.pattern, rel)
+	return ok
+}
@@ -0,0 +1,195 @@
+package cookies_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchGlob(t *testing.T) {
+	requireMatch := func(exp bool, pattern, name string) {
+		act, e := cookies.MatchGlob(pattern, name)
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, exp, act, "MatchGlob(%q, %q)", pattern, name)
+	}
+
+	requireMatch(true, "*.go", "abc.go")
+	requireMatch(false, "*.go", "a/abc.go")
+	requireMatch(true, "**/*.go", "abc.go")
+	requireMatch(true, "**/*.go", "a/b/abc.go")
+	requireMatch(true, "a/**", "a/b/c")
+	requireMatch(true, "a/**/c", "a/c")
+	requireMatch(true, "a/**/c", "a/b/b/c")
+	requireMatch(false, "a/**/c", "a/b/d")
+	requireMatch(true, "**/*.{go,mod}", "x/go.mod")
+	requireMatch(true, "{a,b/{c,d}}/*.txt", "b/d/abc.txt")
+	requireMatch(false, "{a,b/{c,d}}/*.txt", "b/e/abc.txt")
+
+	_, e := cookies.MatchGlob("[", "abc")
+	require.NotNil(t, e)
+}
+
+func collectWalk(t *testing.T, root string, opts cookies.WalkOptions) []string {
+	var act []string
+	e := cookies.Walk(root, opts, func(entry cookies.WalkEntry) error {
+		act = append(act, entry.Rel)
+		return nil
+	})
+	require.Nil(t, e, "%+v", e)
+	return act
+}
+
+func TestWalk(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		".gitignore":        []byte("# Comment\n*.log\nbuild/\n!keep.log\n/top.txt\n"),
+		"abc.go":            []byte("Weatherwax"),
+		"top.txt":           []byte("Ogg"),
+		"debug.log":         []byte("Garlick"),
+		"keep.log":          []byte("Nanny"),
+		"build/out.go":      []byte("Magrat"),
+		"a/top.txt":         []byte("Tiffany"),
+		"a/b/xyz.go":        []byte("Rincewind"),
+		"a/b/.gitignore":    []byte("secret.go\n"),
+		"a/b/secret.go":     []byte("Luggage"),
+		"vendor/lib/lib.go": []byte("Librarian"),
+	}))
+
+	act := collectWalk(t, temp, cookies.WalkOptions{
+		IgnoreFile: ".gitignore",
+		Exclude:    []string{"vendor", "**/.gitignore"},
+	})
+	require.Equal(t, []string{
+		"a/b/xyz.go",
+		"a/top.txt",
+		"abc.go",
+		"keep.log",
+	}, act)
+
+	act = collectWalk(t, temp, cookies.WalkOptions{
+		Patterns: []string{"**/*.go"},
+		MaxDepth: 2,
+	})
+	require.Equal(t, []string{"abc.go", "build/out.go"}, act)
+
+	act = collectWalk(t, temp, cookies.WalkOptions{
+		Patterns: []string{"nothing"},
+		Exclude:  []string{"build", "vendor/lib"},
+		Dirs:     true,
+	})
+	require.Equal(t, []string{"a", "a/b", "vendor"}, act)
+}
+
+func TestWalk_Symlinks(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		"a/abc.txt": []byte("Weatherwax"),
+	}))
+	require.Nil(t, os.Symlink(filepath.Join(temp, "a"), temp+"/link"))
+	require.Nil(t, os.Symlink("..", temp+"/a/loop"))
+
+	act := collectWalk(t, temp, cookies.WalkOptions{})
+	require.Equal(t, []string{"a/abc.txt", "a/loop", "link"}, act)
+
+	e := cookies.Walk(temp, cookies.WalkOptions{FollowSymlinks: true}, func(cookies.WalkEntry) error {
+		return nil
+	})
+	require.NotNil(t, e)
+
+	act = collectWalk(t, temp, cookies.WalkOptions{
+		Exclude:        []string{"**/loop"},
+		FollowSymlinks: true,
+	})
+	require.Equal(t, []string{"a/abc.txt", "link/abc.txt"}, act)
+}
+
+func TestWalk_SkipDirFile(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		"a/abc.txt":  []byte("Weatherwax"),
+		"a/skip.txt": []byte("Ogg"),
+		"a/xyz.txt":  []byte("Garlick"),
+		"b/xyz.txt":  []byte("Nanny"),
+	}))
+
+	var act []string
+	e := cookies.Walk(temp, cookies.WalkOptions{}, func(entry cookies.WalkEntry) error {
+		act = append(act, entry.Rel)
+		if entry.Rel == "a/skip.txt" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	require.Nil(t, e, "%+v", e)
+	require.Equal(t, []string{"a/abc.txt", "a/skip.txt", "b/xyz.txt"}, act)
+}
+
+func TestWalker(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	require.Nil(t, cookies.CreateFiles(temp, os.ModePerm, map[string][]byte{
+		"a/abc.txt": []byte("Weatherwax"),
+		"b/xyz.txt": []byte("Ogg"),
+	}))
+
+	w, e := cookies.NewWalker(temp, cookies.WalkOptions{Dirs: true})
+	require.Nil(t, e, "%+v", e)
+
+	var act []string
+	for w.Next() {
+		entry := w.Entry()
+		act = append(act, entry.Rel)
+		if entry.Rel == "a" {
+			w.SkipDir()
+		}
+	}
+
+	require.Nil(t, w.Err())
+	require.Equal(t, []string{"a", "b", "b/xyz.txt"}, act)
+
+	_, e = cookies.NewWalker(temp+"/a/abc.txt", cookies.WalkOptions{})
+	require.NotNil(t, e)
+}
+
+func TestWalkFS(t *testing.T) {
+	t.Parallel()
+	cookiestest.EachFS(t, func(t *testing.T, fsys cookies.FS, root string) {
+		require.Nil(t, cookies.CreateFilesFS(fsys, root, os.ModePerm, map[string][]byte{
+			".gitignore":    []byte("*.log\n"),
+			"abc.go":        []byte("Weatherwax"),
+			"debug.log":     []byte("Garlick"),
+			"a/b/xyz.go":    []byte("Rincewind"),
+			"a/b/.hidden":   []byte("Luggage"),
+			"vendor/lib.go": []byte("Librarian"),
+		}))
+		require.Nil(t, fsys.Symlink(filepath.Join(root, "a"), filepath.Join(root, "link")))
+		require.Nil(t, fsys.Symlink("..", filepath.Join(root, "a", "loop")))
+
+		var act []string
+		e := cookies.WalkFS(fsys, root, cookies.WalkOptions{
+			IgnoreFile:     ".gitignore",
+			Patterns:       []string{"**/*.go"},
+			Exclude:        []string{"vendor", "**/loop"},
+			FollowSymlinks: true,
+		}, func(entry cookies.WalkEntry) error {
+			act = append(act, entry.Rel)
+			return nil
+		})
+
+		require.Nil(t, e, "%+v", e)
+		require.Equal(t, []string{"a/b/xyz.go", "abc.go", "link/b/xyz.go"}, act)
+
+		e = cookies.WalkFS(fsys, root, cookies.WalkOptions{FollowSymlinks: true}, func(cookies.WalkEntry) error {
+			return nil
+		})
+		require.NotNil(t, e)
+	})
+}
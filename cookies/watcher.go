@@ -0,0 +1,293 @@
+package cookies
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WatchOp is the kind of change a WatchEvent describes.
+type WatchOp int
+
+const (
+	WatchCreate WatchOp = iota + 1
+	WatchModify
+	WatchDelete
+	WatchRename
+)
+
+// String returns the name of the operation.
+func (op WatchOp) String() string {
+	switch op {
+	case WatchCreate:
+		return "create"
+	case WatchModify:
+		return "modify"
+	case WatchDelete:
+		return "delete"
+	case WatchRename:
+		return "rename"
+	default:
+		return "unknown"
+	}
+}
+
+// WatchEvent is a single change to a watched file.
+type WatchEvent struct {
+	Op      WatchOp
+	Path    string
+	OldPath string // Previous path, only set for WatchRename
+}
+
+// WatcherOptions configures a Watcher.
+type WatcherOptions struct {
+	Interval time.Duration // Time between polls, 500ms if zero
+	Debounce time.Duration // Quiet time required before delivering changes
+	Hash     bool          // Compare file content hashes instead of size and mtime
+}
+
+// Watcher polls a set of files for changes. Changes are collected until no
+// more have been seen for the debounce period then delivered as a single
+// batch of events, sorted by path, on the Events channel.
+type Watcher struct {
+	Events <-chan []WatchEvent
+	Errors <-chan error // Errors are dropped if not received promptly
+
+	patterns  []string
+	opts      WatcherOptions
+	events    chan []WatchEvent
+	errors    chan error
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+	polls     int32 // Completed polls, accessed atomically
+}
+
+type watchState struct {
+	size  int64
+	mtime time.Time
+	hash  []byte
+}
+
+// NewWatcher starts a new Watcher polling the files matching 'patterns'. Each
+// pattern is a path or filepath.Glob pattern, matching directories are
+// watched recursively. The current state of the files is captured before
+// returning so only later changes produce events. The watcher stops when
+// 'ctx' is cancelled or Close is called.
+func NewWatcher(ctx context.Context, patterns []string, opts WatcherOptions) (*Watcher, error) {
+
+	for _, p := range patterns {
+		if _, e := filepath.Match(p, ""); e != nil {
+			return nil, Wrap(e, "Bad pattern %q", p)
+		}
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = 500 * time.Millisecond
+	}
+
+	w := &Watcher{
+		patterns: patterns,
+		opts:     opts,
+		events:   make(chan []WatchEvent),
+		errors:   make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+	w.Events, w.Errors = w.events, w.errors
+
+	baseline, e := w.scan()
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	go w.run(ctx, baseline)
+	return w, nil
+}
+
+// Close stops the watcher and closes its channels.
+func (w *Watcher) Close() error {
+	w.closeOnce.Do(w.cancel)
+	<-w.done
+	return nil
+}
+
+func (w *Watcher) run(ctx context.Context, baseline map[string]watchState) {
+	defer close(w.done)
+	defer close(w.errors)
+	defer close(w.events)
+
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	last := baseline
+	var changedAt time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		curr, e := w.scan()
+		atomic.AddInt32(&w.polls, 1)
+		if e != nil {
+			w.sendErr(e)
+			continue
+		}
+
+		now := time.Now()
+		if len(diffWatchStates(last, curr)) > 0 {
+			changedAt = now
+		}
+		last = curr
+
+		if changedAt.IsZero() || now.Sub(changedAt) < w.opts.Debounce {
+			continue
+		}
+
+		events := diffWatchStates(baseline, curr)
+		baseline, changedAt = curr, time.Time{}
+		if len(events) == 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case w.events <- events:
+		}
+	}
+}
+
+func (w *Watcher) sendErr(e error) {
+	select {
+	case w.errors <- e:
+	default:
+	}
+}
+
+// scan captures the state of every file matching the watchers patterns.
+func (w *Watcher) scan() (map[string]watchState, error) {
+	states := map[string]watchState{}
+
+	for _, p := range w.patterns {
+		matches, e := filepath.Glob(p)
+		if e != nil {
+			return nil, e
+		}
+
+		for _, m := range matches {
+			if e := filepath.Walk(m, w.walkFunc(states)); e != nil {
+				return nil, e
+			}
+		}
+	}
+
+	return states, nil
+}
+
+func (w *Watcher) walkFunc(states map[string]watchState) filepath.WalkFunc {
+	return func(f string, info os.FileInfo, e error) error {
+		if os.IsNotExist(e) {
+			return nil // Removed mid scan, the next scan will notice
+		}
+		if e != nil {
+			return e
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		s := watchState{size: info.Size(), mtime: info.ModTime()}
+		if w.opts.Hash {
+			if s.hash, e = hashWatchedFile(f); os.IsNotExist(e) {
+				return nil
+			} else if e != nil {
+				return e
+			}
+		}
+
+		states[f] = s
+		return nil
+	}
+}
+
+func hashWatchedFile(f string) ([]byte, error) {
+	file, e := os.Open(f)
+	if e != nil {
+		return nil, e
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, e := io.Copy(h, file); e != nil {
+		return nil, e
+	}
+	return h.Sum(nil), nil
+}
+
+func (s watchState) sameContent(o watchState) bool {
+	if s.hash != nil || o.hash != nil {
+		return string(s.hash) == string(o.hash)
+	}
+	return s.size == o.size && s.mtime.Equal(o.mtime)
+}
+
+// diffWatchStates returns the events that transform 'old' into 'new'. A file
+// deleted and another created with the same content is considered a rename.
+func diffWatchStates(old, new map[string]watchState) []WatchEvent {
+
+	var events []WatchEvent
+	var created, deleted []string
+
+	for f, s := range new {
+		prev, ok := old[f]
+		switch {
+		case !ok:
+			created = append(created, f)
+		case !prev.sameContent(s):
+			events = append(events, WatchEvent{Op: WatchModify, Path: f})
+		}
+	}
+
+	for f := range old {
+		if _, ok := new[f]; !ok {
+			deleted = append(deleted, f)
+		}
+	}
+
+	sort.Strings(created)
+	sort.Strings(deleted)
+	renamed := map[string]bool{}
+
+	for _, d := range deleted {
+		ev := WatchEvent{Op: WatchDelete, Path: d}
+		for _, c := range created {
+			if !renamed[c] && old[d].size == new[c].size && old[d].sameContent(new[c]) {
+				ev = WatchEvent{Op: WatchRename, Path: c, OldPath: d}
+				renamed[c] = true
+				break
+			}
+		}
+		events = append(events, ev)
+	}
+
+	for _, c := range created {
+		if !renamed[c] {
+			events = append(events, WatchEvent{Op: WatchCreate, Path: c})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+	return events
+}
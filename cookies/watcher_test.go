@@ -0,0 +1,105 @@
+package cookies_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+	"github.com/PaulioRandall/go-cookies/cookies/cookiestest"
+	"github.com/stretchr/testify/require"
+)
+
+func requireWatchEvents(t *testing.T, w *cookies.Watcher, exps ...cookies.WatchEvent) {
+	select {
+	case act := <-w.Events:
+		require.Equal(t, exps, act)
+	case <-time.After(time.Second):
+		require.Fail(t, "Timed out waiting for watch events")
+	}
+}
+
+// waitForPolls waits until the watcher has completed 'n' more polls.
+func waitForPolls(t *testing.T, w *cookies.Watcher, n int32) {
+	exp := w.Polls() + n
+	deadline := time.Now().Add(time.Second)
+	for w.Polls() < exp {
+		if time.Now().After(deadline) {
+			require.Fail(t, "Timed out waiting for the watcher to poll")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	abc := filepath.Join(temp, "abc.txt")
+	xyz := filepath.Join(temp, "xyz.txt")
+
+	w, e := cookies.NewWatcher(context.Background(), []string{temp}, cookies.WatcherOptions{
+		Interval: 5 * time.Millisecond,
+		Debounce: 20 * time.Millisecond,
+		Hash:     true,
+	})
+	require.Nil(t, e, "%+v", e)
+	defer w.Close()
+
+	require.Nil(t, ioutil.WriteFile(abc, []byte("Weatherwax"), 0666))
+	requireWatchEvents(t, w, cookies.WatchEvent{Op: cookies.WatchCreate, Path: abc})
+
+	require.Nil(t, ioutil.WriteFile(abc, []byte("Ogg"), 0666))
+	requireWatchEvents(t, w, cookies.WatchEvent{Op: cookies.WatchModify, Path: abc})
+
+	require.Nil(t, os.Rename(abc, xyz))
+	requireWatchEvents(t, w, cookies.WatchEvent{Op: cookies.WatchRename, Path: xyz, OldPath: abc})
+
+	require.Nil(t, os.Remove(xyz))
+	requireWatchEvents(t, w, cookies.WatchEvent{Op: cookies.WatchDelete, Path: xyz})
+}
+
+func TestWatcher_Debounce(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	abc := filepath.Join(temp, "abc.txt")
+	xyz := filepath.Join(temp, "xyz.txt")
+
+	w, e := cookies.NewWatcher(context.Background(), []string{temp + "/*.txt"}, cookies.WatcherOptions{
+		Interval: 5 * time.Millisecond,
+		Debounce: 200 * time.Millisecond,
+	})
+	require.Nil(t, e, "%+v", e)
+	defer w.Close()
+
+	// The second poll is certain to start after the write
+	require.Nil(t, ioutil.WriteFile(abc, []byte("Weatherwax"), 0666))
+	waitForPolls(t, w, 2)
+	require.Nil(t, ioutil.WriteFile(abc, []byte("Weatherwax Esme"), 0666))
+	require.Nil(t, ioutil.WriteFile(xyz, []byte("Ogg"), 0666))
+
+	requireWatchEvents(t, w,
+		cookies.WatchEvent{Op: cookies.WatchCreate, Path: abc},
+		cookies.WatchEvent{Op: cookies.WatchCreate, Path: xyz},
+	)
+}
+
+func TestWatcher_Close(t *testing.T) {
+	temp := cookiestest.NewWorkspace(t).Root
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w, e := cookies.NewWatcher(ctx, []string{temp}, cookies.WatcherOptions{})
+	require.Nil(t, e, "%+v", e)
+
+	cancel()
+	require.Nil(t, w.Close())
+	require.Nil(t, w.Close())
+
+	_, ok := <-w.Events
+	require.False(t, ok)
+
+	_, e = cookies.NewWatcher(ctx, []string{"["}, cookies.WatcherOptions{})
+	require.NotNil(t, e)
+}
@@ -1,10 +1,15 @@
 package quick
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/PaulioRandall/go-cookies/cookies"
 	"github.com/PaulioRandall/go-cookies/go/goexe"
 )
 
@@ -15,6 +20,23 @@ func Clean(buildDir string) {
 	ExitIfErr(e, "Failed to remove build directory: %s", buildDir)
 }
 
+// WithLock runs 'f' while holding a file lock on 'lockFile' so concurrent runs
+// don't clean or build over each other. The lock file must not be within any
+// directory 'f' removes. If the lock is not acquired within 'timeout' then the
+// error is immediately printed and the program exits with code 1. If 'f' exits
+// the program the lock is released by the operating system or, where flock is
+// not supported, found to be stale by the next run.
+func WithLock(lockFile string, timeout time.Duration, f func()) {
+	l := cookies.NewFileLock(lockFile, cookies.DefaultLockMode)
+	e := l.LockTimeout(timeout)
+	ExitIfErr(e, "Failed to lock: %s", lockFile)
+
+	f()
+
+	e = l.Unlock()
+	ExitIfErr(e, "Failed to unlock: %s", lockFile)
+}
+
 // Setup creates the build directory and any parents. If an error occurs it is
 // immediately printed and the program exits with code 1.
 func Setup(buildDir string, mode os.FileMode) {
@@ -58,6 +80,59 @@ func Vet(root string, args ...string) {
 	ExitIfErr(e, "Vet failed")
 }
 
+// DiskUsage prints the disk usage of the directories within 'root' as a
+// table, or JSON if 'asJSON' is true. If an error occurs it is immediately
+// printed and the program exits with code 1.
+func DiskUsage(root string, opts cookies.UsageOptions, asJSON bool) {
+	usage, e := cookies.DiskUsage(root, opts)
+	ExitIfErr(e, "Failed to measure disk usage: %s", root)
+	printReport(usage, asJSON, func(w io.Writer) error {
+		return cookies.WriteUsageTable(w, usage)
+	})
+}
+
+// Duplicates prints the groups of duplicate files within 'root' as a table,
+// or JSON if 'asJSON' is true. If an error occurs it is immediately printed
+// and the program exits with code 1.
+func Duplicates(root string, opts cookies.WalkOptions, asJSON bool) {
+	groups, e := cookies.FindDuplicates(root, cookies.SHA256, opts)
+	ExitIfErr(e, "Failed to find duplicates: %s", root)
+	printReport(groups, asJSON, func(w io.Writer) error {
+		return cookies.WriteDuplicatesTable(w, groups)
+	})
+}
+
+// Normalise normalises the text files within 'root', or in check mode
+// reports those that need it, printing each offending file and its issues.
+// The number of offending files is returned. If an error occurs it is
+// immediately printed and the program exits with code 1.
+func Normalise(root string, opts cookies.NormaliseOptions) int {
+	results, e := cookies.NormaliseDir(root, opts)
+	ExitIfErr(e, "Failed to normalise files: %s", root)
+
+	verb := "Fixed"
+	if opts.Check {
+		verb = "Bad"
+	}
+	for _, r := range results {
+		fmt.Printf("%s: %s (%s)\n", verb, r.Path, strings.Join(r.Issues, ", "))
+	}
+
+	return len(results)
+}
+
+func printReport(v interface{}, asJSON bool, table func(io.Writer) error) {
+	if !asJSON {
+		e := table(os.Stdout)
+		ExitIfErr(e, "Failed to print report")
+		return
+	}
+
+	data, e := json.MarshalIndent(v, "", "  ")
+	ExitIfErr(e, "Failed to print report")
+	fmt.Println(string(data))
+}
+
 // Run executes 'exe' within 'buildDir' returning the exit code. If an
 // error occurs it is immediately printed and the program exits with code 1.
 func Run(buildDir, exe string, args ...string) int {
@@ -70,27 +145,27 @@ func Run(buildDir, exe string, args ...string) int {
 	return code
 }
 
-// Usage error prints the error message, then the program usage, and finally
-// exits the program with code 1.
+// Usage error prints the error message, then the program usage, to stderr and
+// finally exits the program with code 1.
 func UsageErr(usage, msg string, args ...interface{}) {
 	const code = 1
-	fmt.Printf("Exit: %d\n", code)
-	fmt.Printf("Error: "+msg+"\n\n", args...)
-	fmt.Println(usage)
+	fmt.Fprintf(os.Stderr, "Exit: %d\n", code)
+	fmt.Fprintf(os.Stderr, "Error: "+msg+"\n\n", args...)
+	fmt.Fprintln(os.Stderr, usage)
 	os.Exit(code)
 }
 
-// ExitIfErr prints the error message, then the cause, and finally exits the
-// program with code 1 if the cause is not nil else the function returns without
-// side effect.
+// ExitIfErr prints the error message, then the cause, to stderr and finally
+// exits the program with code 1 if the cause is not nil else the function
+// returns without side effect.
 func ExitIfErr(cause error, msg string, args ...interface{}) {
 	if cause == nil {
 		return
 	}
 	const code = 1
-	fmt.Printf("Exit: %d\n", code)
-	fmt.Printf("Error: "+msg+"\n", args...)
-	fmt.Printf("Caused by: %+v\n", cause)
+	fmt.Fprintf(os.Stderr, "Exit: %d\n", code)
+	fmt.Fprintf(os.Stderr, "Error: "+msg+"\n", args...)
+	fmt.Fprintf(os.Stderr, "Caused by: %+v\n", cause)
 	os.Exit(code)
 }
 
@@ -101,3 +176,12 @@ func AbsPath(rel string) string {
 	ExitIfErr(e, "Failed to identify path")
 	return p
 }
+
+// FindRoot returns the nearest directory, from the working directory upwards,
+// that contains any of the 'markers' files. If an error occurs it is
+// immediately printed and the program exits with code 1.
+func FindRoot(markers ...string) string {
+	root, _, e := cookies.FindRoot(".", markers...)
+	ExitIfErr(e, "Failed to find project root")
+	return root
+}
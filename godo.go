@@ -5,13 +5,17 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/PaulioRandall/go-cookies/cookies"
 	"github.com/PaulioRandall/go-cookies/go/quick"
 )
 
 var (
-	ROOT      = quick.AbsPath(".")
+	ROOT      = quick.FindRoot("godo.go", "go.mod")
 	BUILD     = filepath.Join(ROOT, "build")
+	LOCK_FILE = BUILD + ".lock"
+	LOCK_WAIT = 5 * time.Minute
 	PROJ_PATH = "github.com/PaulioRandall/go-cookies"
 	MAIN_PKG  = "cmd"
 	USAGE     = `Usage:
@@ -19,7 +23,10 @@ var (
 	clean      Remove build files
 	build      Build -> format -> vet
 	test       Build -> format -> test -> vet
-	run        Build -> format -> test -> vet -> run`
+	run        Build -> format -> test -> vet -> run
+	usage      Show the largest directories, 'usage json' for JSON
+	dupes      Show duplicate files, 'dupes json' for JSON
+	normalise  Check line endings, BOMs, and whitespace, 'normalise fix' to fix`
 )
 
 var (
@@ -31,6 +38,18 @@ var (
 	FMT_ARGS  = []string{"./..."}
 	TEST_ARGS = []string{"-timeout", "2s", "./..."}
 	VET_ARGS  = []string{"./..."}
+	SCAN_OPTS = cookies.WalkOptions{Exclude: []string{".git", "build", "vendor"}}
+	// Go files are left to gofmt as trimming would alter raw string literals
+	TEXT_OPTS = cookies.NormaliseOptions{
+		LineEnding:   cookies.EndingLF,
+		BOM:          cookies.BOMStrip,
+		TrimTrailing: true,
+		FinalNewline: true,
+		Walk: cookies.WalkOptions{
+			Patterns: []string{"**/*.{md,txt,mod,sum}", "**/.gitignore"},
+			Exclude:  SCAN_OPTS.Exclude,
+		},
+	}
 )
 
 func main() {
@@ -47,36 +66,65 @@ func main() {
 		fmt.Println(USAGE)
 
 	case "clean":
-		quick.Clean(BUILD)
+		quick.WithLock(LOCK_FILE, LOCK_WAIT, func() {
+			quick.Clean(BUILD)
+		})
 
 	case "build":
-		quick.Clean(BUILD)
-		quick.Setup(BUILD, os.ModePerm)
-		quick.Build(ROOT, BUILD_ARGS...)
-		quick.Fmt(ROOT, FMT_ARGS...)
-		quick.Vet(ROOT, VET_ARGS...)
+		quick.WithLock(LOCK_FILE, LOCK_WAIT, func() {
+			quick.Clean(BUILD)
+			quick.Setup(BUILD, os.ModePerm)
+			quick.Build(ROOT, BUILD_ARGS...)
+			quick.Fmt(ROOT, FMT_ARGS...)
+			quick.Vet(ROOT, VET_ARGS...)
+		})
 
 	case "test":
-		quick.Clean(BUILD)
-		quick.Setup(BUILD, os.ModePerm)
-		quick.Build(ROOT, BUILD_ARGS...)
-		quick.Fmt(ROOT, FMT_ARGS...)
-		quick.Test(ROOT, TEST_ARGS...)
-		quick.Vet(ROOT, VET_ARGS...)
+		quick.WithLock(LOCK_FILE, LOCK_WAIT, func() {
+			quick.Clean(BUILD)
+			quick.Setup(BUILD, os.ModePerm)
+			quick.Build(ROOT, BUILD_ARGS...)
+			quick.Fmt(ROOT, FMT_ARGS...)
+			quick.Test(ROOT, TEST_ARGS...)
+			quick.Vet(ROOT, VET_ARGS...)
+		})
 
 	case "run":
-		quick.Clean(BUILD)
-		quick.Setup(BUILD, os.ModePerm)
-		quick.Build(ROOT, BUILD_ARGS...)
-		quick.Fmt(ROOT, FMT_ARGS...)
-		quick.Test(ROOT, TEST_ARGS...)
-		quick.Vet(ROOT, VET_ARGS...)
-		code = quick.Run(BUILD, MAIN_PKG)
+		quick.WithLock(LOCK_FILE, LOCK_WAIT, func() {
+			quick.Clean(BUILD)
+			quick.Setup(BUILD, os.ModePerm)
+			quick.Build(ROOT, BUILD_ARGS...)
+			quick.Fmt(ROOT, FMT_ARGS...)
+			quick.Test(ROOT, TEST_ARGS...)
+			quick.Vet(ROOT, VET_ARGS...)
+
+			// Held while running so the binary isn't cleaned away beneath it
+			code = quick.Run(BUILD, MAIN_PKG)
+		})
+
+	case "usage":
+		opts := cookies.UsageOptions{Depth: 3, Top: 20, Walk: SCAN_OPTS}
+		quick.DiskUsage(ROOT, opts, wantJSON(args))
+
+	case "dupes":
+		quick.Duplicates(ROOT, SCAN_OPTS, wantJSON(args))
+
+	case "normalise":
+		opts := TEXT_OPTS
+		opts.Check = len(args) < 2 || strings.ToLower(args[1]) != "fix"
+		if n := quick.Normalise(ROOT, opts); n > 0 && opts.Check {
+			code = 1
+		}
 
 	default:
 		quick.UsageErr(USAGE, "Unknown command argument %q", cmd)
 	}
 
-	fmt.Printf("\nExit: %d\n", code)
+	// Stdout may hold a JSON report so the trailer goes to stderr
+	fmt.Fprintf(os.Stderr, "\nExit: %d\n", code)
 	os.Exit(code)
 }
+
+func wantJSON(args []string) bool {
+	return len(args) > 1 && strings.ToLower(args[1]) == "json"
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaulioRandall/go-cookies/cookies"
+)
+
+// TestGodo is not a real test, it runs godo with the arguments in GODO_ARGS
+// when started as a subprocess by runGodo.
+func TestGodo(t *testing.T) {
+	args, ok := os.LookupEnv("GODO_ARGS")
+	if !ok {
+		return
+	}
+
+	os.Args = []string{"godo"}
+	if e := json.Unmarshal([]byte(args), &os.Args); e != nil {
+		panic(e)
+	}
+	main()
+}
+
+// runGodo runs godo with 'args' in a subprocess and returns what it wrote to
+// stdout.
+func runGodo(t *testing.T, args ...string) []byte {
+	data, e := json.Marshal(append([]string{"godo"}, args...))
+	require.Nil(t, e)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestGodo$")
+	cmd.Env = append(os.Environ(), "GODO_ARGS="+string(data))
+
+	stdout := bytes.Buffer{}
+	cmd.Stdout = &stdout
+	require.Nil(t, cmd.Run(), "%s", stdout.String())
+
+	return stdout.Bytes()
+}
+
+func TestGodo_UsageJSON(t *testing.T) {
+	var usage []cookies.DirUsage
+	out := runGodo(t, "usage", "json")
+	require.Nil(t, json.Unmarshal(out, &usage), "%s", out)
+	require.NotEmpty(t, usage)
+	require.Equal(t, ".", usage[0].Path)
+}
+
+func TestGodo_DupesJSON(t *testing.T) {
+	var groups []cookies.DuplicateGroup
+	out := runGodo(t, "dupes", "json")
+	require.Nil(t, json.Unmarshal(out, &groups), "%s", out)
+}